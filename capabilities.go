@@ -0,0 +1,121 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// defaultCaps is the set of capabilities Docker grants containers by default.
+var defaultCaps = []string{
+	"AUDIT_WRITE",
+	"CHOWN",
+	"DAC_OVERRIDE",
+	"FOWNER",
+	"FSETID",
+	"KILL",
+	"MKNOD",
+	"NET_BIND_SERVICE",
+	"NET_RAW",
+	"SETFCAP",
+	"SETGID",
+	"SETPCAP",
+	"SETUID",
+	"SYS_CHROOT",
+}
+
+// allCaps is the set of all Linux capabilities, as granted by --cap-add ALL.
+var allCaps = []string{
+	"AUDIT_CONTROL",
+	"AUDIT_READ",
+	"AUDIT_WRITE",
+	"BLOCK_SUSPEND",
+	"BPF",
+	"CHECKPOINT_RESTORE",
+	"CHOWN",
+	"DAC_OVERRIDE",
+	"DAC_READ_SEARCH",
+	"FOWNER",
+	"FSETID",
+	"IPC_LOCK",
+	"IPC_OWNER",
+	"KILL",
+	"LEASE",
+	"LINUX_IMMUTABLE",
+	"MAC_ADMIN",
+	"MAC_OVERRIDE",
+	"MKNOD",
+	"NET_ADMIN",
+	"NET_BIND_SERVICE",
+	"NET_BROADCAST",
+	"NET_RAW",
+	"PERFMON",
+	"SETFCAP",
+	"SETGID",
+	"SETPCAP",
+	"SETUID",
+	"SYSLOG",
+	"SYS_ADMIN",
+	"SYS_BOOT",
+	"SYS_CHROOT",
+	"SYS_MODULE",
+	"SYS_NICE",
+	"SYS_PACCT",
+	"SYS_PTRACE",
+	"SYS_RAWIO",
+	"SYS_RESOURCE",
+	"SYS_TIME",
+	"SYS_TTY_CONFIG",
+	"WAKE_ALARM",
+}
+
+// normalizeCap returns the capability name in upper case and without the
+// CAP_ prefix, which Docker accepts with or without.
+func normalizeCap(c string) string {
+	return strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(c)), "CAP_")
+}
+
+// effectiveCaps returns the sorted set of capabilities a container ends up
+// with, starting from the default set, removing everything in capDrop, and
+// then adding everything in capAdd. ALL in either list drops or adds every
+// capability.
+func effectiveCaps(capAdd, capDrop []string) []string {
+	caps := make(map[string]bool)
+	for _, c := range defaultCaps {
+		caps[c] = true
+	}
+	for _, c := range capDrop {
+		if c = normalizeCap(c); c == "ALL" {
+			caps = make(map[string]bool)
+		} else {
+			delete(caps, c)
+		}
+	}
+	for _, c := range capAdd {
+		if c = normalizeCap(c); c == "ALL" {
+			for _, c := range allCaps {
+				caps[c] = true
+			}
+		} else {
+			caps[c] = true
+		}
+	}
+	var l []string
+	for c := range caps {
+		l = append(l, c)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// toStrings returns the strings in a JSON array decoded into an interface{}.
+// Elements that are not strings are skipped.
+func toStrings(v interface{}) []string {
+	l, _ := v.([]interface{})
+	var s []string
+	for _, v := range l {
+		if v, ok := v.(string); ok {
+			s = append(s, v)
+		}
+	}
+	return s
+}
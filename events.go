@@ -0,0 +1,98 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// emitEvents turns on writing a DecisionEvent for every authorization request
+// to standard output.
+var emitEvents bool
+
+// DecisionEvent is the record of a single authorization decision, written as
+// one line of JSON to standard output when -events is set. The field names
+// are part of the plugin's interface, and must not change.
+type DecisionEvent struct {
+	// Time is when the decision was made, in RFC 3339 format.
+	Time string `json:"time"`
+
+	// RequestID is the ID of the request, the same as the RequestID logged
+	// for it.
+	RequestID string `json:"request_id"`
+
+	// User and AuthMethod are the authenticated user and authentication
+	// method, empty for unauthenticated requests.
+	User       string `json:"user"`
+	AuthMethod string `json:"auth_method"`
+
+	// Method and URI are the HTTP method and request URI of the API request.
+	Method string `json:"method"`
+	URI    string `json:"uri"`
+
+	// Image is the image of the container being created, if any.
+	Image string `json:"image,omitempty"`
+
+	// RulesFired are the reason codes of every rule that denied or warned
+	// about the request.
+	RulesFired []string `json:"rules_fired"`
+
+	// Action is the outcome: allow, warn (allowed with warnings), deny, or
+	// error if the request could not be parsed.
+	Action string `json:"action"`
+
+	// Code is the reason code of the deny, if the request was denied.
+	Code string `json:"code,omitempty"`
+}
+
+// eventWriter writes decision events, one per line.
+var eventWriter = struct {
+	sync.Mutex
+	w io.Writer
+}{w: os.Stdout}
+
+// newDecisionEvent returns the event for decision d on request req, with the
+// request ID id. err is true if the request could not be parsed.
+func newDecisionEvent(id string, req *authzReq, body map[string]interface{}, d decision, err bool) DecisionEvent {
+	e := DecisionEvent{
+		Time:       time.Now().UTC().Format(time.RFC3339Nano),
+		RequestID:  id,
+		User:       req.User,
+		AuthMethod: req.UserAuthNMethod,
+		Method:     req.RequestMethod,
+		URI:        redactURI(req.RequestURI),
+		RulesFired: append([]string{}, d.Warnings...),
+	}
+	e.Image = getString(body, "Image")
+	switch {
+	case err:
+		e.Action = "error"
+	case !d.Allow:
+		e.Action = actionDeny
+		e.Code = d.Code
+		e.RulesFired = append(e.RulesFired, d.Code)
+	case len(d.Warnings) > 0:
+		e.Action = actionWarn
+	default:
+		e.Action = actionAllow
+	}
+	return e
+}
+
+// emit writes the event as a line of JSON.
+func (e DecisionEvent) emit() {
+	b, err := json.Marshal(e)
+	if err != nil {
+		log.Errorf("Error encoding decision event: %v", err)
+		return
+	}
+	eventWriter.Lock()
+	defer eventWriter.Unlock()
+	if _, err := eventWriter.w.Write(append(b, '\n')); err != nil {
+		log.Errorf("Error writing decision event: %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// exemptions are settings for rules that can exempt containers from the
+// rule's check, by image, by label, or by the user creating them.
+type exemptions struct {
+	// ExemptImages is a list of glob patterns for images that are exempt from
+	// the rule. Patterns are matched against both the full image reference and
+	// the image name without its tag or digest.
+	ExemptImages []string `json:"exempt-images"`
+
+	// ExemptLabels is a map of container labels to values. Containers with any
+	// of these labels set to the matching value are exempt from the rule.
+	ExemptLabels map[string]string `json:"exempt-labels"`
+
+	// ExemptUsers is a list of users that are exempt from the rule. Only
+	// authenticated users can be exempt, as the user is the common name of
+	// the client's TLS certificate.
+	ExemptUsers []string `json:"exempt-users"`
+}
+
+// exempt returns true if the container being created in ctx is exempt.
+func (e *exemptions) exempt(ctx *evalContext) bool {
+	return imageInList(ctx.image(), e.ExemptImages) || ctx.hasLabel(e.ExemptLabels) ||
+		(ctx.req.authenticated() && inList(e.ExemptUsers, ctx.req.User))
+}
+
+// imageName returns the supplied image reference with any tag or digest
+// removed.
+func imageName(ref string) string {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref = ref[:i]
+	}
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// imageInList returns true if the supplied image reference, or its name
+// without the tag or digest, matches any of the glob patterns in list.
+func imageInList(ref string, list []string) bool {
+	for _, p := range list {
+		if ok, _ := path.Match(p, ref); ok {
+			return true
+		}
+		if ok, _ := path.Match(p, imageName(ref)); ok {
+			return true
+		}
+	}
+	return false
+}
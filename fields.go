@@ -0,0 +1,54 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// The get functions fetch a field of a JSON object decoded into a
+// map[string]interface{}, returning the zero value if the field is missing,
+// null, or of an unexpected type. Request bodies can come from any client or
+// daemon version, and may have fields of other platforms, so rules always use
+// these rather than assuming a type.
+
+// getString returns the string field key of m.
+func getString(m map[string]interface{}, key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+// getBool returns the boolean field key of m.
+func getBool(m map[string]interface{}, key string) bool {
+	v, _ := m[key].(bool)
+	return v
+}
+
+// getMap returns the object field key of m.
+func getMap(m map[string]interface{}, key string) map[string]interface{} {
+	v, _ := m[key].(map[string]interface{})
+	return v
+}
+
+// getSlice returns the array field key of m.
+func getSlice(m map[string]interface{}, key string) []interface{} {
+	v, _ := m[key].([]interface{})
+	return v
+}
+
+// decodeBody decodes an API request body into a JSON object. A few endpoints
+// take a JSON array instead, such as the plugin privileges of /plugins/pull,
+// which decode to an empty object, as rules for them decode the body
+// themselves.
+func decodeBody(b []byte) (map[string]interface{}, error) {
+	var v interface{}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return v, nil
+	case []interface{}:
+		return make(map[string]interface{}), nil
+	}
+	return nil, fmt.Errorf("expected a JSON object or array")
+}
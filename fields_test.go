@@ -0,0 +1,67 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFieldAccessors(t *testing.T) {
+	body, err := decodeBody([]byte(`{
+		"String": "s", "Bool": true, "Map": {"k": "v"}, "Slice": ["a", 1],
+		"Number": 1.5, "Null": null
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []string{"String", "Bool", "Map", "Slice", "Number", "Null", "Missing"}
+	cases := []struct {
+		name string
+		get  func(m map[string]interface{}, key string) interface{}
+		key  string
+		want interface{}
+	}{
+		{"getString", func(m map[string]interface{}, k string) interface{} { return getString(m, k) }, "String", "s"},
+		{"getBool", func(m map[string]interface{}, k string) interface{} { return getBool(m, k) }, "Bool", true},
+		{"getMap", func(m map[string]interface{}, k string) interface{} { return getMap(m, k) }, "Map", obj{"k": "v"}},
+		{"getSlice", func(m map[string]interface{}, k string) interface{} { return getSlice(m, k) }, "Slice", arr{"a", 1.0}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			zero := reflect.Zero(reflect.TypeOf(c.want)).Interface()
+			for _, k := range keys {
+				want := zero
+				if k == c.key {
+					want = c.want
+				}
+				if got := c.get(body, k); !reflect.DeepEqual(got, want) {
+					t.Errorf("%s: expected %#v, got %#v", k, want, got)
+				}
+			}
+			if got := c.get(nil, c.key); !reflect.DeepEqual(got, zero) {
+				t.Errorf("nil map: expected %#v, got %#v", zero, got)
+			}
+		})
+	}
+}
+
+// TestMalformedHostConfig runs create requests with every inspected HostConfig
+// field set to each JSON type through all rules, which must not panic.
+func TestMalformedHostConfig(t *testing.T) {
+	var settings []string
+	for _, newRule := range ruleRegistry {
+		settings = append(settings, newRule().Name()+".enabled=true")
+	}
+	p := testPolicy(t, settings...)
+	values := []interface{}{"x", -1.5, true, arr{1, obj{}, nil}, obj{"a": arr{}}, nil}
+	for _, v := range values {
+		hc := obj{}
+		for k := range inspectedHostConfigFields {
+			hc[k] = v
+		}
+		body := obj{"Image": v, "Env": v, "Labels": v, "User": v, "Cmd": v, "Healthcheck": v, "HostConfig": hc}
+		for _, uri := range []string{"/containers/create", "/containers/abc/update", "/containers/abc/exec"} {
+			evaluate(t, p, newAuthzReq("POST", uri, body))
+			evaluate(t, p, newAuthzReq("POST", uri, hc))
+		}
+	}
+}
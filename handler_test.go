@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestActivateHandler(t *testing.T) {
+	w := httptest.NewRecorder()
+	activateHandler(w, httptest.NewRequest("POST", "/Plugin.Activate", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %d", w.Code)
+	}
+	if got, want := w.Body.String(), `{"Implements":["authz"]}`; got != want {
+		t.Fatalf("expected body %s, got %s", want, got)
+	}
+}
+
+func TestAuthzHandlerFixtures(t *testing.T) {
+	cases := []struct {
+		path    string
+		fixture string
+		allow   bool
+		msg     string
+	}{
+		{"/AuthZPlugin.AuthZReq", "create_allowed.json", true, "Request allowed"},
+		{"/AuthZPlugin.AuthZReq", "create_userns_host.json", false, "userns=host is not allowed"},
+		{"/AuthZPlugin.AuthZRes", "create_response.json", true, "Request allowed"},
+		{"/AuthZPlugin.AuthZRes", "create_userns_host.json", false, "userns=host is not allowed"},
+	}
+	for _, c := range cases {
+		t.Run(c.path+" "+c.fixture, func(t *testing.T) {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", c.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			w, resp := serveBytes(t, c.path, b)
+			if w.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %d: %s", w.Code, w.Body)
+			}
+			if resp.Allow != c.allow || resp.Msg != c.msg || resp.Err != "" {
+				t.Fatalf("expected allowed %t with message %q, got %+v", c.allow, c.msg, resp)
+			}
+		})
+	}
+}
+
+func TestAuthzHandlerErrors(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		body string
+		code int
+		err  string
+	}{
+		{"unknown path", "/AuthZPlugin.Other", `{}`, http.StatusBadRequest, "/AuthZPlugin.Other not found on this server"},
+		{"bad JSON", "/AuthZPlugin.AuthZReq", `{`, http.StatusBadRequest, "Error parsing request JSON: unexpected end of JSON input"},
+		{"empty nested body", "/AuthZPlugin.AuthZReq", `{"RequestMethod":"POST","RequestURI":"/containers/create","RequestBody":"e30K"}`, http.StatusOK, ""},
+		{"nested body not JSON", "/AuthZPlugin.AuthZReq", `{"RequestMethod":"POST","RequestURI":"/containers/create","RequestBody":"bm9wZQ=="}`, http.StatusBadRequest, "Error reading original request JSON: invalid character 'o' in literal null (expecting 'u')"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w, resp := serveBytes(t, c.path, []byte(c.body))
+			if w.Code != c.code || resp.Err != c.err {
+				t.Fatalf("expected status %d with error %q, got %d with %+v", c.code, c.err, w.Code, resp)
+			}
+		})
+	}
+}
+
+// TestAuthzHandlerConcurrent sends requests from many goroutines while the
+// policy is reloaded, and checks that in_flight_requests is back to 0 after.
+// Run with -race.
+func TestAuthzHandlerConcurrent(t *testing.T) {
+	useTestPolicy(t, activePolicy())
+	var bodies [][]byte
+	for _, f := range []string{"create_allowed.json", "create_userns_host.json"} {
+		b, err := ioutil.ReadFile(filepath.Join("testdata", f))
+		if err != nil {
+			t.Fatal(err)
+		}
+		bodies = append(bodies, b)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				w := httptest.NewRecorder()
+				authzHandler(w, httptest.NewRequest("POST", "/AuthZPlugin.AuthZReq", bytes.NewReader(bodies[(i+j)%len(bodies)])))
+				if w.Code != http.StatusOK {
+					t.Errorf("expected status 200, got %d: %s", w.Code, w.Body)
+					return
+				}
+			}
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			if err := reloadPolicy(); err != nil {
+				t.Errorf("error reloading policy: %v", err)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	if n := metricInFlight.Value(); n != 0 {
+		t.Fatalf("expected no requests in flight, got %d", n)
+	}
+}
+
+// TestAuthzHandlerResponseBody checks the exact response bytes, which must be
+// JSON with no trailing newline or other framing.
+func TestAuthzHandlerResponseBody(t *testing.T) {
+	cases := []struct {
+		name string
+		path string
+		body []byte
+		code int
+		want string
+	}{
+		{"allowed", "/AuthZPlugin.AuthZReq", newCreateReq(nil), http.StatusOK, `{"Allow":true,"Msg":"Request allowed","Err":""}`},
+		{"denied", "/AuthZPlugin.AuthZReq", newCreateReq(obj{"UsernsMode": "host"}), http.StatusOK, `{"Allow":false,"Msg":"userns=host is not allowed","Err":""}`},
+		{"error", "/AuthZPlugin.AuthZReq", []byte(`{`), http.StatusBadRequest, `{"Allow":false,"Msg":"Request failed with error","Err":"Error parsing request JSON: unexpected end of JSON input"}`},
+		{"not found", "/AuthZPlugin.Other", []byte(`{}`), http.StatusBadRequest, `{"Allow":false,"Msg":"Request failed with error","Err":"/AuthZPlugin.Other not found on this server"}`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			authzHandler(w, httptest.NewRequest("POST", c.path, bytes.NewReader(c.body)))
+			if w.Code != c.code {
+				t.Fatalf("expected status %d, got %d", c.code, w.Code)
+			}
+			if ct := w.Header()["Content-Type"]; len(ct) != 1 || ct[0] != "application/json" {
+				t.Fatalf("expected Content-Type application/json, got %q", ct)
+			}
+			if got := w.Body.String(); got != c.want {
+				t.Fatalf("expected body %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+func TestActivationMsg(t *testing.T) {
+	msg := activationMsg()
+	if got := msg["Implements"]; len(got) != 1 || got[0] != "authz" {
+		t.Fatalf("expected Implements [authz], got %v", got)
+	}
+	msg["Implements"][0] = "changed"
+	if got := activationMsg()["Implements"][0]; got != "authz" {
+		t.Fatalf("changing a returned message changed the next one to %v", got)
+	}
+
+	old := pluginCapabilities
+	defer func() { pluginCapabilities = old }()
+	pluginCapabilities = append([]string{}, old...)
+	pluginCapabilities = append(pluginCapabilities, "logdriver")
+	w := httptest.NewRecorder()
+	activateHandler(w, httptest.NewRequest("POST", "/Plugin.Activate", nil))
+	if got, want := w.Body.String(), `{"Implements":["authz","logdriver"]}`; got != want {
+		t.Fatalf("expected body %s, got %s", want, got)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// obj is shorthand for a JSON object in request bodies.
+type obj = map[string]interface{}
+
+// arr is shorthand for a JSON array in request bodies.
+type arr = []interface{}
+
+func TestMain(m *testing.M) {
+	log.SetOutput(ioutil.Discard)
+	p, err := loadPolicy("", nil)
+	if err != nil {
+		panic(err)
+	}
+	currentPolicy.Store(p)
+	os.Exit(m.Run())
+}
+
+// newAuthzReq returns the authorization request the daemon sends for an API
+// request with method and uri. body is marshaled to JSON and nested as the raw
+// RequestBody, the same way the daemon does it, unless it is nil. A
+// []byte body is nested as is.
+func newAuthzReq(method, uri string, body interface{}) authzReq {
+	req := authzReq{RequestMethod: method, RequestURI: uri}
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		req.RequestBody = b
+	default:
+		req.RequestBody, _ = json.Marshal(b)
+	}
+	return req
+}
+
+// createBody returns the body of a container create request for the busybox
+// image with hostConfig, which may be nil.
+func createBody(hostConfig obj) obj {
+	body := obj{"Image": "busybox"}
+	if hostConfig != nil {
+		body["HostConfig"] = hostConfig
+	}
+	return body
+}
+
+// updateReq returns the request for docker update of container abc, with the
+// resources in set changed. The CLI sends every resource, with zero for those
+// that are not changed, and a null PidsLimit.
+func updateReq(set obj) authzReq {
+	body := obj{
+		"BlkioWeight": 0, "CpuShares": 0, "CpuPeriod": 0, "CpuQuota": 0,
+		"CpuRealtimePeriod": 0, "CpuRealtimeRuntime": 0, "CpusetCpus": "",
+		"CpusetMems": "", "Memory": 0, "MemoryReservation": 0, "MemorySwap": 0,
+		"KernelMemory": 0, "NanoCpus": 0, "PidsLimit": nil,
+		"RestartPolicy": obj{"Name": "", "MaximumRetryCount": 0},
+	}
+	for k, v := range set {
+		body[k] = v
+	}
+	return newAuthzReq("POST", "/v1.41/containers/abc/update", body)
+}
+
+// newCreateReq returns the HTTP request body of an AuthZReq for creating a
+// container with hostConfig, as the daemon sends it to the plugin.
+func newCreateReq(hostConfig obj) []byte {
+	b, _ := json.Marshal(newAuthzReq("POST", "/v1.41/containers/create", createBody(hostConfig)))
+	return b
+}
+
+// testPolicy loads a policy without a policy file, with settings in the form
+// rule.setting=value applied the same way as -set.
+func testPolicy(t *testing.T, settings ...string) *policy {
+	t.Helper()
+	var l settingList
+	for _, s := range settings {
+		if err := l.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := loadPolicy("", l)
+	if err != nil {
+		t.Fatalf("error loading policy: %v", err)
+	}
+	return p
+}
+
+// testPolicyFile loads a policy from the policy file contents in file, with
+// settings applied as for testPolicy.
+func testPolicyFile(t *testing.T, file string, settings ...string) *policy {
+	t.Helper()
+	f, err := ioutil.TempFile("", "denyusernshost-policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.WriteString(file); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	var l settingList
+	for _, s := range settings {
+		if err := l.Set(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	p, err := loadPolicy(f.Name(), l)
+	if err != nil {
+		t.Fatalf("error loading policy: %v", err)
+	}
+	return p
+}
+
+// useTestPolicy makes p the active policy until the end of the test.
+func useTestPolicy(t *testing.T, p *policy) {
+	old := activePolicy()
+	currentPolicy.Store(p)
+	t.Cleanup(func() { currentPolicy.Store(old) })
+}
+
+// evaluate runs the request phase of req through p, and returns the decision
+// along with the data logged for it.
+func evaluate(t *testing.T, p *policy, req authzReq) (decision, obj) {
+	t.Helper()
+	body := obj{}
+	if len(req.RequestBody) > 0 {
+		var err error
+		if body, err = decodeBody(req.RequestBody); err != nil {
+			t.Fatalf("error decoding request body: %v", err)
+		}
+	}
+	logData := obj{}
+	d := p.evaluate(&evalContext{req: &req, body: body, logData: logData})
+	return d, logData
+}
+
+// serve sends req to the handler as an authorization request to path, ie:
+// /AuthZPlugin.AuthZReq, and returns the recorded response along with its
+// decoded body.
+func serve(t *testing.T, path string, req authzReq) (*httptest.ResponseRecorder, authResponse) {
+	t.Helper()
+	b, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveBytes(t, path, b)
+}
+
+// serveBytes sends the raw authorization request b to the handler at path,
+// the same as serve.
+func serveBytes(t *testing.T, path string, b []byte) (*httptest.ResponseRecorder, authResponse) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	authzHandler(w, httptest.NewRequest("POST", path, bytes.NewReader(b)))
+	var resp authResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("error parsing response %q: %v", w.Body.Bytes(), err)
+	}
+	return w, resp
+}
+
+// ruleCase is a request for a table-driven rule test, along with the decision
+// it must get. msg is the expected message of a deny, and is not checked if
+// empty.
+type ruleCase struct {
+	name  string
+	req   authzReq
+	allow bool
+	msg   string
+}
+
+// runRuleCases evaluates each case against p, checking the decision.
+func runRuleCases(t *testing.T, p *policy, cases []ruleCase) {
+	t.Helper()
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, _ := evaluate(t, p, c.req)
+			switch {
+			case d.Allow != c.allow:
+				t.Fatalf("expected allowed to be %t, got %t (%s)", c.allow, d.Allow, d.Msg)
+			case c.msg != "" && d.Msg != c.msg:
+				t.Fatalf("expected message %q, got %q", c.msg, d.Msg)
+			}
+		})
+	}
+}
+
+func TestNewCreateReq(t *testing.T) {
+	var req authzReq
+	if err := json.Unmarshal(newCreateReq(obj{"UsernsMode": "host"}), &req); err != nil {
+		t.Fatal(err)
+	}
+	var body struct {
+		Image      string
+		HostConfig struct{ UsernsMode string }
+	}
+	if err := json.Unmarshal(req.RequestBody, &body); err != nil {
+		t.Fatalf("RequestBody is not nested JSON: %v", err)
+	}
+	if req.RequestMethod != "POST" || req.RequestURI != "/v1.41/containers/create" || body.Image != "busybox" || body.HostConfig.UsernsMode != "host" {
+		t.Fatalf("unexpected request %+v with body %+v", req, body)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// defaultLogLevel is the log level used when neither -log-level nor -debug
+// is given.
+const defaultLogLevel = "info"
+
+// logLevel is the log level set from the command line.
+var logLevel = defaultLogLevel
+
+// debugLogging is set by -debug, an alias for -log-level debug kept for
+// backward compatibility.
+var debugLogging bool
+
+// logLevels are the accepted log level names, from the most to the least
+// verbose.
+var logLevels = []string{"trace", "debug", "info", "warn", "error"}
+
+// setupLogLevel sets the level of the standard logger from the -log-level and
+// -debug flags. -debug takes precedence. trace is accepted for familiarity,
+// but is the same as debug, as there are no messages more verbose than debug.
+func setupLogLevel() error {
+	name := strings.ToLower(logLevel)
+	if debugLogging {
+		name = "debug"
+	}
+	if !inList(logLevels, name) {
+		return fmt.Errorf("unknown log level %q, must be one of: %s", logLevel, strings.Join(logLevels, ", "))
+	}
+	if name == "trace" {
+		name = "debug"
+	}
+	level, err := log.ParseLevel(name)
+	if err != nil {
+		return err
+	}
+	log.SetLevel(level)
+	return nil
+}
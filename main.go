@@ -11,20 +11,29 @@ import (
 	"os/signal"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 
 	log "github.com/Sirupsen/logrus"
 	"golang.org/x/sys/unix"
 )
 
-// activationMsg is a map[string]interface{} representation of the JSON
-// message for what this plugin implements.
-var activationMsg = map[string][]string{
-	"Implements": []string{"authz"},
+// pluginCapabilities are the plugin types this plugin implements, as
+// advertised to the daemon on activation.
+var pluginCapabilities = []string{"authz"}
+
+// activationMsg returns the JSON message for what this plugin implements.
+func activationMsg() map[string][]string {
+	return map[string][]string{
+		"Implements": append([]string{}, pluginCapabilities...),
+	}
 }
 
+// defaultSocketPath is the default path to the plugin socket.
+const defaultSocketPath = "/run/docker/plugins/denyusernshost.sock"
+
 // socketPath is the path to the plugin socket.
-const socketPath = "/run/docker/plugins/denyusernshost.sock"
+var socketPath string
 
 var (
 	// logBodyItems is a list of items to log from the immediate request body.
@@ -33,7 +42,7 @@ var (
 
 	// logHostConfigItems is a list of items to log from the HostConfig in the
 	// request body. Fields are skipped if they are not defined.
-	logHostConfigItems = []string{"VolumesFrom", "Binds"}
+	logHostConfigItems = []string{"VolumesFrom", "Binds", "Mounts", "Sysctls"}
 )
 
 // authzReq is a struct representing an authorization request.
@@ -66,6 +75,12 @@ type authzReq struct {
 	RequestHeader map[string][]string
 }
 
+// authenticated returns true if the client was authenticated, ie: it
+// connected with a TLS client certificate.
+func (r *authzReq) authenticated() bool {
+	return r.User != ""
+}
+
 // authResponse is a struct representing a Docker authz plugin API response.
 //
 // This response format is used for both /AuthZPlugin.AuthZReq and
@@ -103,13 +118,26 @@ func listenUnix() net.Listener {
 	return socket
 }
 
-// denyUsernsHost denys all requests and responses that have
-// { "HostConfig": { "UsernsMode": "host" } } set in the request body.
+// activateHandler answers the daemon's plugin activation request with the
+// plugin types this plugin implements.
+func activateHandler(w http.ResponseWriter, r *http.Request) {
+	respBody, _ := json.Marshal(activationMsg())
+	log.Infof("%s %s - 200 - (Plugin activation request from docker daemon)", r.Method, r.URL.Path)
+	io.WriteString(w, string(respBody))
+}
+
+// authzHandler parses authorization requests and responses from the Docker
+// daemon and runs them through the enabled rules in the current policy,
+// denying the request if any of the rules deny it.
 //
 // This is the main workhorse function of our plugin.
-func denyUsernsHost(w http.ResponseWriter, r *http.Request) {
+func authzHandler(w http.ResponseWriter, r *http.Request) {
+	metricInFlight.Add(1)
+	defer metricInFlight.Add(-1)
 	defer r.Body.Close()
 	var req authzReq
+	var d decision
+	var p *policy
 	code := http.StatusBadRequest
 	body := make([]byte, r.ContentLength)
 	data := make(map[string]interface{})
@@ -117,15 +145,21 @@ func denyUsernsHost(w http.ResponseWriter, r *http.Request) {
 	resp := authResponse{
 		Msg: "Request failed with error",
 	}
+	// The ID is replaced with the one from the API request headers, if any,
+	// once the request is parsed.
+	id := newUUID()
+	reqLog := log.WithField("RequestID", id)
 
 	if r.ContentLength <= 0 {
 		resp.Err = "Request has empty body"
+		metricParseErrors.Add(1)
 		goto response
 	}
 
 	if n, err := io.ReadFull(r.Body, body); err != nil {
-		log.Debugf("Error reading: read %d bytes of Content-Length of %d", n, r.ContentLength)
+		reqLog.Debugf("Error reading: read %d bytes of Content-Length of %d", n, r.ContentLength)
 		resp.Err = fmt.Sprintf("Error reading request: %v", err)
+		metricParseErrors.Add(1)
 		goto response
 	}
 
@@ -133,26 +167,43 @@ func denyUsernsHost(w http.ResponseWriter, r *http.Request) {
 	case "/AuthZPlugin.AuthZReq", "/AuthZPlugin.AuthZRes":
 		if err := json.Unmarshal(body, &req); err != nil {
 			resp.Err = fmt.Sprintf("Error parsing request JSON: %v", err)
+			metricParseErrors.Add(1)
 			goto response
 		}
+		if h, ok := headerRequestID(&req); ok {
+			id = h
+			reqLog = log.WithField("RequestID", id)
+		}
 
-		if len(req.RequestBody) > 0 {
-			log.Debugf("Parsing original API request body: %s", req.RequestBody)
-			if err := json.Unmarshal(req.RequestBody, &data); err != nil {
+		// The daemon usually leaves out bodies that aren't JSON, but build
+		// contexts and plugin rootfs archives are tar streams, and are never
+		// parsed just in case.
+		if path := apiPath(req.RequestURI); len(req.RequestBody) > 0 && path != "/build" && path != "/plugins/create" {
+			reqLog.Debugf("Parsing original API request body: %s", req.RequestBody)
+			var err error
+			if data, err = decodeBody(req.RequestBody); err != nil {
 				resp.Err = fmt.Sprintf("Error reading original request JSON: %v", err)
+				metricBodyParseErrors.Add(1)
 				goto response
 			}
 		}
 	default:
 		resp.Err = fmt.Sprintf("%s not found on this server", r.URL.Path)
+		metricUnknownURLs.Add(1)
 		goto response
 	}
 
+	p = activePolicy()
 	for _, k := range logBodyItems {
 		if v, ok := data[k]; ok && v != nil && v != reflect.Zero(reflect.TypeOf(v)) {
 			logData[k] = v
 		}
 	}
+	// Secrets are redacted here, before any rule runs, so that they are
+	// redacted whichever rule denies the request.
+	if _, ok := logData["Env"]; ok && p.secretEnv != nil && p.secretEnv.Redact {
+		logData["Env"] = p.secretEnv.redactEnv(toStrings(data["Env"]))
+	}
 
 	if v, ok := data["HostConfig"].(map[string]interface{}); ok {
 		for _, k := range logHostConfigItems {
@@ -160,53 +211,170 @@ func denyUsernsHost(w http.ResponseWriter, r *http.Request) {
 				logData[k] = v
 			}
 		}
-		if v, ok := v["UsernsMode"]; ok && v.(string) == "host" && strings.HasSuffix(req.RequestURI, "/containers/create") {
-			// Apparently you don't send 403 for a successful deny.
-			code = http.StatusOK
-			resp.Msg = "userns=host is not allowed"
-			goto response
+		// This is only ever logged, and never changes the decision.
+		if logUnknownFields {
+			if unknown := unknownHostConfigFields(v); len(unknown) > 0 {
+				reqLog.Infof("HostConfig fields not inspected by any rule: %s", strings.Join(unknown, ", "))
+			}
 		}
 	}
 
+	d = p.evaluate(&evalContext{
+		req:        &req,
+		body:       data,
+		logData:    logData,
+		response:   r.URL.Path == "/AuthZPlugin.AuthZRes",
+		log:        reqLog,
+		reqContext: r.Context(),
+	})
+	if len(d.Warnings) > 0 {
+		logData["Warnings"] = d.Warnings
+	}
+	if !d.Allow {
+		// Apparently you don't send 403 for a successful deny.
+		code = http.StatusOK
+		resp.Msg = d.Msg
+		logData["Code"] = d.Code
+		goto response
+	}
+
 	code = http.StatusOK
 	resp.Allow = true
 	resp.Msg = "Request allowed"
 
 response:
+	if resp.Err == "" {
+		if resp.Allow {
+			metricDecisions.Add("allowed", 1)
+		} else {
+			metricDecisions.Add("denied", 1)
+			metricDenies.Add(d.Code, 1)
+		}
+	}
+	authStr := "unauthenticated"
+	if req.authenticated() {
+		authStr = fmt.Sprintf("user %q via %s", req.User, req.UserAuthNMethod)
+	}
+	if emitEvents {
+		newDecisionEvent(id, &req, data, d, resp.Err != "").emit()
+	}
 	logDataStr, _ := json.Marshal(logData)
-	log.Infof("%s %s - %d (Allowed: %t) - %s %s - %s", r.Method, r.URL.Path, code, resp.Allow, req.RequestMethod, req.RequestURI, logDataStr)
+	// Denies and errors are logged as warnings, so that they are still logged
+	// with -log-level warn.
+	logf := reqLog.Infof
+	if !resp.Allow {
+		logf = reqLog.Warnf
+	}
+	logf("%s %s - %d (Allowed: %t) - %s %s - %s - %s", r.Method, r.URL.Path, code, resp.Allow, req.RequestMethod, redactURI(req.RequestURI), authStr, logDataStr)
 
 	respBody, _ := json.Marshal(resp)
-	log.Debugf("Response JSON: %s", string(respBody))
-	w.Header().Add("Content-Type", "application/json")
-	http.Error(w, string(respBody), code)
+	reqLog.Debugf("Response JSON: %s", string(respBody))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(respBody)
 }
 
 func init() {
-	var debug bool
-	flag.BoolVar(&debug, "debug", false, "Enable debug logging")
-	flag.Parse()
-	if debug {
-		log.SetLevel(log.DebugLevel)
+	// Flag defaults come from the environment, so that flags take precedence.
+	debugEnv, _ := strconv.ParseBool(os.Getenv(envName("debug")))
+	flag.BoolVar(&debugLogging, "debug", debugEnv, "Enable debug logging, the same as -log-level debug (env: DUH_DEBUG)")
+	if v := os.Getenv(envName("log-level")); v != "" {
+		logLevel = v
+	}
+	flag.StringVar(&logLevel, "log-level", logLevel, "Log level: "+strings.Join(logLevels, ", ")+" (env: DUH_LOG_LEVEL)")
+	socketEnv := os.Getenv(envName("socket"))
+	if socketEnv == "" {
+		socketEnv = defaultSocketPath
+	}
+	flag.StringVar(&socketPath, "socket", socketEnv, "Path to the plugin socket (env: DUH_SOCKET)")
+	flag.StringVar(&configPath, "config", os.Getenv(envName("config")), "Path to the JSON policy file (env: DUH_CONFIG)")
+	syslogEnv, _ := strconv.ParseBool(os.Getenv(envName("log-syslog")))
+	flag.BoolVar(&logSyslog.Enabled, "log-syslog", syslogEnv, "Send log output to the local syslog (env: DUH_LOG_SYSLOG)")
+	if v := os.Getenv(envName("log-syslog-facility")); v != "" {
+		logSyslog.Facility = v
 	}
+	flag.StringVar(&logSyslog.Facility, "log-syslog-facility", logSyslog.Facility, "Syslog facility (env: DUH_LOG_SYSLOG_FACILITY)")
+	if v := os.Getenv(envName("log-syslog-tag")); v != "" {
+		logSyslog.Tag = v
+	}
+	flag.StringVar(&logSyslog.Tag, "log-syslog-tag", logSyslog.Tag, "Syslog tag (env: DUH_LOG_SYSLOG_TAG)")
+	if v := os.Getenv(envName("log-stderr")); v != "" {
+		logSyslog.Stderr, _ = strconv.ParseBool(v)
+	}
+	flag.BoolVar(&logSyslog.Stderr, "log-stderr", logSyslog.Stderr, "Also log to stderr when logging to syslog (env: DUH_LOG_STDERR)")
+	flag.StringVar(&metricsAddr, "metrics", os.Getenv(envName("metrics")), "TCP address to serve metrics on, ie: 127.0.0.1:9323 (env: DUH_METRICS)")
+	eventsEnv, _ := strconv.ParseBool(os.Getenv(envName("events")))
+	flag.BoolVar(&emitEvents, "events", eventsEnv, "Write a JSON decision event for every request to stdout (env: DUH_EVENTS)")
+	unknownFieldsEnv, _ := strconv.ParseBool(os.Getenv(envName("log-unknown-fields")))
+	flag.BoolVar(&logUnknownFields, "log-unknown-fields", unknownFieldsEnv, "Log HostConfig fields that no rule inspects, to notice new API fields (env: DUH_LOG_UNKNOWN_FIELDS)")
+	flag.BoolVar(&selfTest, "selftest", false, "Check that the handler denies a userns=host container and allows a ping before serving, and exit if not")
+	flag.StringVar(&testRequestPath, "test-request", "", "Evaluate the API request body in this file (- for stdin) against the policy, print a report, and exit")
+	flag.StringVar(&testRequestURI, "test-request-uri", "/containers/create", "API request URI to evaluate the -test-request body as")
+	if v, ok := os.LookupEnv(envName("request-id-header")); ok {
+		requestIDHeader = v
+	}
+	flag.StringVar(&requestIDHeader, "request-id-header", requestIDHeader, "API request header to take request IDs from, or empty to always generate them (env: DUH_REQUEST_ID_HEADER)")
+	flag.StringVar(&defaultActionFlag, "default-action", "", "Posture for container creation, allow or deny, the same as -set default-action (env: DUH_DEFAULT_ACTION)")
+	flag.Var(&settingFlags, "set", "Override a policy setting, in the form rule.setting=value (can be repeated)")
 }
 
+// defaultActionFlag is the value of -default-action, which is added to the
+// -set flags when given.
+var defaultActionFlag string
+
 func main() {
+	// Flags are parsed here rather than in init, so that tests can run with
+	// the flags of the test binary.
+	flag.Parse()
+	if defaultActionFlag != "" {
+		settingFlags = append(settingList{{key: "default-action", value: defaultActionFlag, source: "flag -default-action"}}, settingFlags...)
+	}
+	if err := setupLogLevel(); err != nil {
+		errExit(1, "Error setting log level: %v", err)
+	}
+	if err := logSyslog.setup(); err != nil {
+		errExit(1, "Error setting up syslog logging: %v", err)
+	}
 	log.Info("denyusernshost Docker authz plugin starting.")
+	log.Info("Configuration precedence: flags > environment (DUH_*) > policy file > defaults")
+	if configPath != "" {
+		log.Infof("Loading policy file %s", configPath)
+	}
+	p, err := loadPolicy(configPath, append(envSettings(), settingFlags...))
+	if err != nil {
+		errExit(1, "Error loading policy: %v", err)
+	}
+	currentPolicy.Store(p)
+	log.Infof("Enabled rules: %s", strings.Join(p.ruleNames(), ", "))
+	if testRequestPath != "" {
+		os.Exit(runTestRequest(os.Stdout, p, testRequestPath, testRequestURI))
+	}
+	if selfTest {
+		if err := runSelfTest(); err != nil {
+			errExit(1, "Self-test failed, not serving: %v", err)
+		}
+	}
 	socket := listenUnix()
-	http.HandleFunc("/Plugin.Activate", func(w http.ResponseWriter, r *http.Request) {
-		respBody, _ := json.Marshal(activationMsg)
-		log.Infof("%s %s - 200 - (Plugin activation request from docker daemon)", r.Method, r.URL.Path)
-		io.WriteString(w, string(respBody))
-	})
-	http.HandleFunc("/AuthZPlugin.AuthZReq", denyUsernsHost)
-	http.HandleFunc("/AuthZPlugin.AuthZRes", denyUsernsHost)
-	log.Info("Press CTRL-C or send SIGTERM to close the server")
+	registerHandlers(http.DefaultServeMux)
+	if metricsAddr != "" {
+		serveMetrics()
+	}
+	log.Info("Press CTRL-C or send SIGTERM to close the server, or send SIGHUP to reload the policy")
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, unix.SIGHUP)
+	go func() {
+		for range hup {
+			log.Info("SIGHUP received, reloading policy.")
+			if err := reloadPolicy(); err != nil {
+				log.Errorf("Error reloading policy, keeping the current policy: %v", err)
+			}
+		}
+	}()
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, unix.SIGTERM)
 	go func() {
 		s := <-c
-		log.Infof("%s received, shutting down.", s.String())
+		log.Infof("%s received, shutting down with %d requests in flight.", s.String(), metricInFlight.Value())
 		socket.Close()
 		os.Remove(socketPath)
 		os.Exit(0)
@@ -214,6 +382,18 @@ func main() {
 	log.Fatal(http.Serve(socket, nil))
 }
 
+// registerHandlers registers the plugin API handlers on mux.
+func registerHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/Plugin.Activate", activateHandler)
+	mux.HandleFunc("/AuthZPlugin.AuthZReq", authzHandler)
+	mux.HandleFunc("/AuthZPlugin.AuthZRes", authzHandler)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		metricUnknownURLs.Add(1)
+		log.Infof("%s %s - 404 - (Unknown URL)", r.Method, r.URL.Path)
+		http.NotFound(w, r)
+	})
+}
+
 // errExit exits with an error message, and the supplied code.
 func errExit(code int, format string, a ...interface{}) {
 	fmt.Fprintf(os.Stderr, format, a...)
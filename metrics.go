@@ -0,0 +1,60 @@
+package main
+
+import (
+	"expvar"
+	"net"
+	"net/http"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// metricsAddr is the TCP address to serve metrics on. Metrics are not served
+// when empty.
+var metricsAddr string
+
+// Counters exposed on the metrics endpoint. These are published with expvar,
+// so they are also reachable at /debug/vars on the plugin socket.
+var (
+	// metricDecisions counts authorization decisions by result, allowed or
+	// denied.
+	metricDecisions = expvar.NewMap("decisions")
+
+	// metricDenies counts denied requests by reason code.
+	metricDenies = expvar.NewMap("denies")
+
+	// metricWarnings counts warnings from rules with the warn action, by
+	// reason code.
+	metricWarnings = expvar.NewMap("warnings")
+
+	// metricParseErrors counts authorization requests from the daemon that
+	// could not be read or parsed.
+	metricParseErrors = expvar.NewInt("request_parse_errors")
+
+	// metricBodyParseErrors counts authorization requests where the original
+	// API request body (RequestBody) could not be parsed.
+	metricBodyParseErrors = expvar.NewInt("request_body_parse_errors")
+
+	// metricUnknownURLs counts requests to URLs that the plugin does not
+	// serve.
+	metricUnknownURLs = expvar.NewInt("unknown_url_requests")
+
+	// metricInFlight is the number of authorization requests being handled.
+	// Unlike the others, this is a gauge, and should return to zero when the
+	// plugin is idle.
+	metricInFlight = expvar.NewInt("in_flight_requests")
+)
+
+// serveMetrics serves the expvar metrics at /metrics on metricsAddr, in the
+// background.
+func serveMetrics() {
+	l, err := net.Listen("tcp", metricsAddr)
+	if err != nil {
+		errExit(1, "Error listening for metrics on %s: %v", metricsAddr, err)
+	}
+	log.Infof("Serving metrics on http://%s/metrics", l.Addr())
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", expvar.Handler())
+	go func() {
+		log.Errorf("Metrics server stopped: %v", http.Serve(l, mux))
+	}()
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+)
+
+// mount is an entry in HostConfig.Mounts, as populated by docker run --mount,
+// compose v3, and the various SDKs.
+type mount struct {
+	Type          string
+	Source        string
+	Target        string
+	ReadOnly      bool
+	BindOptions   *mountBindOptions
+	TmpfsOptions  *mountTmpfsOptions
+	VolumeOptions *mountVolumeOptions
+}
+
+// mountBindOptions are the options for a bind-type mount.
+type mountBindOptions struct {
+	Propagation string
+}
+
+// mountTmpfsOptions are the options for a tmpfs-type mount.
+type mountTmpfsOptions struct {
+	SizeBytes int64
+	Mode      uint32
+}
+
+// mountVolumeOptions are the options for a volume-type mount.
+type mountVolumeOptions struct {
+	NoCopy       bool
+	Labels       map[string]string
+	DriverConfig *struct {
+		Name    string
+		Options map[string]string
+	}
+}
+
+// mounts decodes HostConfig.Mounts in the request body. Entries that cannot
+// be decoded are skipped.
+func (c *evalContext) mounts() []mount {
+	return decodeMounts(getSlice(c.hostConfig(), "Mounts"))
+}
+
+// decodeMounts decodes a JSON array of mounts, skipping entries that cannot
+// be decoded.
+func decodeMounts(raw []interface{}) []mount {
+	var mounts []mount
+	for _, v := range raw {
+		b, err := json.Marshal(v)
+		if err != nil {
+			continue
+		}
+		var m mount
+		if err := json.Unmarshal(b, &m); err != nil {
+			continue
+		}
+		mounts = append(mounts, m)
+	}
+	return mounts
+}
+
+// hostSource returns the host path that m mounts into the container, or an
+// empty string if it does not mount a host path.
+//
+// Bind-type mounts use Source directly. Volume-type mounts are normally named
+// volumes managed by Docker, but the local driver can also be told to bind a
+// host path (ie: -o type=none -o o=bind -o device=/etc), in which case the
+// device option is the host path. tmpfs-type mounts never have a host source.
+func (m mount) hostSource() string {
+	var src string
+	switch m.Type {
+	case "bind":
+		src = m.Source
+	case "volume":
+		dc := m.VolumeOptions
+		if dc == nil || dc.DriverConfig == nil || dc.DriverConfig.Name != "local" {
+			return ""
+		}
+		for _, o := range strings.Split(dc.DriverConfig.Options["o"], ",") {
+			if o == "bind" || o == "rbind" {
+				src = dc.DriverConfig.Options["device"]
+			}
+		}
+	}
+	if !filepath.IsAbs(src) {
+		return ""
+	}
+	return filepath.Clean(src)
+}
+
+// hostSources returns the host paths mounted into the container being
+// created, from both HostConfig.Binds and HostConfig.Mounts.
+func (c *evalContext) hostSources() []string {
+	var sources []string
+	binds := getSlice(c.hostConfig(), "Binds")
+	for _, v := range binds {
+		bind, _ := v.(string)
+		if src := bindSource(bind); src != "" {
+			sources = append(sources, src)
+		}
+	}
+	for _, m := range c.mounts() {
+		if src := m.hostSource(); src != "" {
+			sources = append(sources, src)
+		}
+	}
+	return sources
+}
+
+// bindSource returns the host path of a HostConfig.Binds entry, in the
+// format src:dst[:opts]. An empty string is returned if the source is not an
+// absolute host path, ie: a named volume.
+func bindSource(bind string) string {
+	src := strings.SplitN(bind, ":", 2)[0]
+	if !filepath.IsAbs(src) {
+		return ""
+	}
+	return filepath.Clean(src)
+}
+
+// pathHasPrefix returns true if path is equal to prefix, or is a sub-path of
+// it. Matching is done on path components, so /etcetera does not match /etc.
+// The root path only matches itself.
+func pathHasPrefix(path, prefix string) bool {
+	prefix = filepath.Clean(prefix)
+	if path == prefix {
+		return true
+	}
+	return prefix != "/" && strings.HasPrefix(path, prefix+"/")
+}
@@ -0,0 +1,69 @@
+package main
+
+import "testing"
+
+// TestMountsParity checks that each bind mount gets the same decision from
+// the default policy whether it is given in HostConfig.Binds, as with -v, or
+// HostConfig.Mounts, as with --mount.
+func TestMountsParity(t *testing.T) {
+	cases := []struct {
+		name  string
+		bind  string
+		mount obj
+		allow bool
+	}{
+		{"data dir", "/srv/data:/data", obj{"Type": "bind", "Source": "/srv/data", "Target": "/data"}, true},
+		{"etc", "/etc:/host/etc:ro", obj{"Type": "bind", "Source": "/etc", "Target": "/host/etc", "ReadOnly": true}, false},
+		{"etc sub-path", "/etc/ssl/certs:/certs", obj{"Type": "bind", "Source": "/etc/ssl/certs", "Target": "/certs"}, false},
+		{"unclean path", "/srv/../etc:/x", obj{"Type": "bind", "Source": "/srv/../etc", "Target": "/x"}, false},
+		{"similar name", "/etcetera:/x", obj{"Type": "bind", "Source": "/etcetera", "Target": "/x"}, true},
+		{"root", "/:/host", obj{"Type": "bind", "Source": "/", "Target": "/host"}, false},
+		{"docker socket", "/var/run/docker.sock:/var/run/docker.sock", obj{"Type": "bind", "Source": "/var/run/docker.sock", "Target": "/var/run/docker.sock"}, false},
+		{"propagation", "/srv/data:/data:rshared", obj{"Type": "bind", "Source": "/srv/data", "Target": "/data", "BindOptions": obj{"Propagation": "rshared"}}, true},
+		{"named volume", "etc:/etc", obj{"Type": "volume", "Source": "etc", "Target": "/etc"}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			binds, _ := evaluate(t, activePolicy(), newAuthzReq("POST", "/containers/create", createBody(obj{"Binds": arr{c.bind}})))
+			mounts, _ := evaluate(t, activePolicy(), newAuthzReq("POST", "/containers/create", createBody(obj{"Mounts": arr{c.mount}})))
+			if binds.Allow != c.allow || mounts.Allow != c.allow {
+				t.Fatalf("expected allowed to be %t, got %t for Binds (%s) and %t for Mounts (%s)", c.allow, binds.Allow, binds.Msg, mounts.Allow, mounts.Msg)
+			}
+			if binds.Msg != mounts.Msg {
+				t.Fatalf("expected the same message, got %q for Binds and %q for Mounts", binds.Msg, mounts.Msg)
+			}
+		})
+	}
+}
+
+func TestMountHostSource(t *testing.T) {
+	localBind := func(o, device string) obj {
+		return obj{"Type": "volume", "Source": "v", "Target": "/v", "VolumeOptions": obj{"DriverConfig": obj{"Name": "local", "Options": obj{"type": "none", "o": o, "device": device}}}}
+	}
+	cases := []struct {
+		name  string
+		mount interface{}
+		want  string
+	}{
+		{"bind", obj{"Type": "bind", "Source": "/srv//data/", "Target": "/data"}, "/srv/data"},
+		{"bind relative", obj{"Type": "bind", "Source": "data", "Target": "/data"}, ""},
+		{"named volume", obj{"Type": "volume", "Source": "etc", "Target": "/etc"}, ""},
+		{"local volume bind", localBind("bind", "/etc"), "/etc"},
+		{"local volume rbind", localBind("ro,rbind", "/proc"), "/proc"},
+		{"local volume nfs", localBind("addr=10.0.0.1", ":/export"), ""},
+		{"tmpfs", obj{"Type": "tmpfs", "Source": "/etc", "Target": "/tmp", "TmpfsOptions": obj{"SizeBytes": 1024}}, ""},
+		{"malformed", obj{"Type": "bind", "Source": 1}, ""},
+		{"not an object", "/etc:/etc", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var got string
+			for _, m := range decodeMounts(arr{c.mount}) {
+				got = m.hostSource()
+			}
+			if got != c.want {
+				t.Fatalf("expected host source %q, got %q", c.want, got)
+			}
+		})
+	}
+}
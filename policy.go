@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// configPath is the path to the JSON policy file. If empty, the default
+// settings for all rules are used.
+var configPath string
+
+// defaultDenyCode is the reason code reported when a deny-by-default policy
+// denies container creation that matches none of its allow conditions.
+const defaultDenyCode = "DUH-DEFAULT-DENY"
+
+// canceledCode is the reason code reported when a request is canceled while
+// it is being evaluated.
+const canceledCode = "DUH-CANCELED"
+
+// currentPolicy holds the *policy that requests are evaluated against. It is
+// only ever replaced as a whole, so that requests being handled during a
+// reload see either the old or the new policy, never a mix of the two.
+var currentPolicy atomic.Value
+
+// activePolicy returns the policy that requests are evaluated against.
+func activePolicy() *policy {
+	return currentPolicy.Load().(*policy)
+}
+
+// reloadPolicy loads the policy file and settings again, and swaps in the
+// new policy if it loads without errors. On error, the current policy is kept.
+func reloadPolicy() error {
+	p, err := loadPolicy(configPath, append(envSettings(), settingFlags...))
+	if err != nil {
+		return err
+	}
+	currentPolicy.Store(p)
+	log.Infof("Policy reloaded, enabled rules: %s", strings.Join(p.ruleNames(), ", "))
+	return nil
+}
+
+// policyFile is the on-disk format of the policy file.
+type policyFile struct {
+	// Rules holds the settings for each rule, keyed by rule name. Rules that
+	// are not mentioned keep their default settings.
+	Rules map[string]json.RawMessage `json:"rules"`
+
+	// CodePrefix prefixes the message sent back to the client on deny with the
+	// reason code of the rule that denied the request.
+	CodePrefix bool `json:"code-prefix"`
+
+	// DefaultAction is the posture for container creation: allow (the
+	// default) allows containers that no rule denies, and deny denies
+	// containers that don't match any of the Allow conditions.
+	DefaultAction string `json:"default-action"`
+
+	// Allow is the list of conditions that allow container creation when
+	// DefaultAction is deny.
+	Allow []namedCondition `json:"allow"`
+
+	webhookOptions
+}
+
+// policy is the set of rules that requests are evaluated against.
+type policy struct {
+	// The enabled rules, in evaluation order.
+	rules []rule
+
+	// Prefix deny messages with the rule's reason code.
+	codePrefix bool
+
+	// The decision webhook, consulted when the rules allow a request. nil if
+	// disabled.
+	webhook *webhook
+
+	// Deny container creation unless the request matches one of allow.
+	defaultDeny bool
+	allow       []namedCondition
+
+	// The secret-env rule, enabled or not, which redacts secrets in the Env
+	// logged with every request.
+	secretEnv *secretEnvRule
+}
+
+// settingFlags holds the settings supplied through -set flags.
+var settingFlags settingList
+
+// loadPolicy builds a policy from the policy file at path, with the settings
+// in overrides applied on top. If path is empty, the default settings for all
+// rules are used. Later entries in overrides take precedence.
+func loadPolicy(path string, overrides []setting) (*policy, error) {
+	f := policyFile{
+		webhookOptions: webhookOptions{WebhookTimeout: duration(5 * time.Second)},
+	}
+	if path != "" {
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := decodeStrict(b, &f); err != nil {
+			return nil, fmt.Errorf("error parsing %s: %v", path, err)
+		}
+	}
+
+	o := make(map[string]setting)
+	for _, s := range overrides {
+		o[s.key] = s
+	}
+	if err := applySettings(&f, "", o); err != nil {
+		return nil, err
+	}
+
+	wh, err := newWebhook(f.webhookOptions)
+	if err != nil {
+		return nil, err
+	}
+	p := &policy{codePrefix: f.CodePrefix, webhook: wh, allow: f.Allow}
+	switch f.DefaultAction {
+	case "", actionAllow:
+	case actionDeny:
+		p.defaultDeny = true
+	default:
+		return nil, fmt.Errorf("invalid default-action %q, expected allow or deny", f.DefaultAction)
+	}
+	for i := range p.allow {
+		c := &p.allow[i]
+		if c.Name == "" {
+			return nil, fmt.Errorf("allow condition %d has no name", i)
+		}
+		if err := c.validate(); err != nil {
+			return nil, fmt.Errorf("allow condition %s: %v", c.Name, err)
+		}
+	}
+	for _, newRule := range ruleRegistry {
+		r := newRule()
+		if b, ok := f.Rules[r.Name()]; ok {
+			if err := decodeStrict(b, r); err != nil {
+				return nil, fmt.Errorf("error parsing settings for rule %q: %v", r.Name(), err)
+			}
+			delete(f.Rules, r.Name())
+		}
+		if err := applySettings(r, r.Name()+".", o); err != nil {
+			return nil, err
+		}
+		if v, ok := r.(validator); ok {
+			if err := v.validate(); err != nil {
+				return nil, fmt.Errorf("invalid settings for rule %q: %v", r.Name(), err)
+			}
+		}
+		switch o := r.options(); o.Action {
+		case "":
+			o.Action = actionDeny
+		case actionDeny, actionWarn, actionAllow:
+		default:
+			return nil, fmt.Errorf("invalid action %q for rule %q, expected deny, warn, or allow", o.Action, r.Name())
+		}
+		for _, ph := range r.options().Phases {
+			if ph != phaseReq && ph != phaseRes {
+				return nil, fmt.Errorf("invalid phase %q for rule %q, expected req or res", ph, r.Name())
+			}
+		}
+		if r, ok := r.(*secretEnvRule); ok {
+			p.secretEnv = r
+		}
+		if r.options().Enabled {
+			p.rules = append(p.rules, r)
+		}
+	}
+	if p.webhook != nil {
+		p.webhook.secretEnv = p.secretEnv
+	}
+	for name := range f.Rules {
+		return nil, fmt.Errorf("unknown rule %q", name)
+	}
+	for _, s := range o {
+		return nil, fmt.Errorf("unknown setting %s from %s", s.key, s.source)
+	}
+	return p, nil
+}
+
+// decodeStrict unmarshals the JSON in b into v, returning an error if there
+// are any fields in b that do not exist in v.
+func decodeStrict(b []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// ruleNames returns the names of the enabled rules in the policy.
+func (p *policy) ruleNames() []string {
+	var names []string
+	for _, r := range p.rules {
+		names = append(names, r.Name())
+	}
+	return names
+}
+
+// evaluate runs the request in ctx through the enabled rules in order,
+// returning the decision of the first rule that denies the request, with the
+// rule's reason code set. If the policy denies by default, container creation
+// must first match one of the allow conditions. Rules are skipped for
+// requests outside of their scope. Denies from rules with the warn action are
+// logged and recorded in the decision's warnings instead, and denies from
+// rules with the allow action are ignored. If no rules deny the request, the
+// decision is left to the webhook if there is one, and otherwise the request
+// is allowed. The webhook is only called in the request phase, as by the
+// response phase the daemon has already acted on the request.
+func (p *policy) evaluate(ctx *evalContext) decision {
+	if p.defaultDeny && ctx.isContainerCreate() && !ctx.response {
+		if d := p.evaluateAllow(ctx); !d.Allow {
+			ctx.logger().Debugf("Request denied by default: %s", d.Msg)
+			return p.withCode(d, defaultDenyCode)
+		}
+	}
+	var warnings []string
+	for _, r := range p.rules {
+		if !r.options().inScope(ctx) {
+			continue
+		}
+		if d, ok := p.canceled(ctx); ok {
+			return d
+		}
+		d := r.Evaluate(ctx)
+		if d.Allow {
+			continue
+		}
+		switch r.options().Action {
+		case actionWarn:
+			ctx.logger().Warnf("Request would be denied by rule %s (%s): %s", r.Name(), r.Code(), d.Msg)
+			metricWarnings.Add(r.Code(), 1)
+			warnings = append(warnings, r.Code())
+			continue
+		case actionAllow:
+			ctx.logger().Debugf("Request allowed by action of rule %s: %s", r.Name(), d.Msg)
+			continue
+		}
+		ctx.logger().Debugf("Request denied by rule %s: %s", r.Name(), d.Msg)
+		d = p.withCode(d, r.Code())
+		d.Warnings = warnings
+		return d
+	}
+	if p.webhook != nil && !ctx.response {
+		if d, ok := p.canceled(ctx); ok {
+			return d
+		}
+		if d := p.webhook.evaluate(ctx); !d.Allow {
+			ctx.logger().Debugf("Request denied by webhook: %s", d.Msg)
+			d = p.withCode(d, webhookCode)
+			d.Warnings = warnings
+			return d
+		}
+	}
+	d := allow()
+	d.Warnings = warnings
+	return d
+}
+
+// evaluateAllow checks container creation against the allow conditions of a
+// deny-by-default policy, returning a deny decision if none of them match.
+func (p *policy) evaluateAllow(ctx *evalContext) decision {
+	for _, c := range p.allow {
+		if c.match(ctx) {
+			ctx.logData["AllowedBy"] = c.Name
+			return allow()
+		}
+	}
+	return deny("containers are denied by default, and this request matches no allow condition")
+}
+
+// canceled returns a deny decision, and true, if the request in ctx was
+// canceled, so that evaluation stops as soon as the daemon gives up on it.
+func (p *policy) canceled(ctx *evalContext) (decision, bool) {
+	err := ctx.context().Err()
+	if err == nil {
+		return decision{}, false
+	}
+	ctx.logger().Warnf("Request evaluation aborted: %v", err)
+	return p.withCode(deny("the request was canceled before it could be checked"), canceledCode), true
+}
+
+// withCode sets the reason code on the deny decision d, and prefixes its
+// message with the code if the policy says to.
+func (p *policy) withCode(d decision, code string) decision {
+	d.Code = code
+	if p.codePrefix {
+		d.Msg = d.Code + ": " + d.Msg
+	}
+	return d
+}
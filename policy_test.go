@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowRule is a rule that blocks until the request is canceled, or a timeout,
+// standing in for a rule that makes a network call.
+type slowRule struct {
+	ruleOptions
+	started chan struct{}
+}
+
+func (r *slowRule) Name() string { return "slow" }
+func (r *slowRule) Code() string { return "DUH-TEST-SLOW" }
+
+func (r *slowRule) Evaluate(ctx *evalContext) decision {
+	close(r.started)
+	select {
+	case <-ctx.context().Done():
+		return allow()
+	case <-time.After(5 * time.Second):
+		return deny("timed out waiting for the request to be canceled")
+	}
+}
+
+// recordRule records that it ran, and allows every request.
+type recordRule struct {
+	ruleOptions
+	ran bool
+}
+
+func (r *recordRule) Name() string { return "record" }
+func (r *recordRule) Code() string { return "DUH-TEST-RECORD" }
+
+func (r *recordRule) Evaluate(ctx *evalContext) decision {
+	r.ran = true
+	return allow()
+}
+
+func TestEvaluateCanceled(t *testing.T) {
+	slow := &slowRule{started: make(chan struct{})}
+	after := &recordRule{}
+	p := &policy{rules: []rule{slow, after}}
+	reqContext, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-slow.started
+		cancel()
+	}()
+	req := newAuthzReq("POST", "/containers/create", createBody(nil))
+	start := time.Now()
+	d := p.evaluate(&evalContext{req: &req, body: createBody(nil), logData: obj{}, reqContext: reqContext})
+	if time.Since(start) > time.Second {
+		t.Fatalf("evaluation took %s, expected it to stop when the request was canceled", time.Since(start))
+	}
+	if d.Allow || d.Code != canceledCode {
+		t.Fatalf("expected a deny with code %s, got %+v", canceledCode, d)
+	}
+	if after.ran {
+		t.Fatal("expected the rules after a canceled one not to run")
+	}
+}
+
+func TestEvaluateCanceledBeforeRules(t *testing.T) {
+	after := &recordRule{}
+	p := &policy{rules: []rule{after}}
+	reqContext, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := newAuthzReq("POST", "/containers/create", createBody(nil))
+	d := p.evaluate(&evalContext{req: &req, body: createBody(nil), logData: obj{}, reqContext: reqContext})
+	if d.Allow || d.Code != canceledCode || after.ran {
+		t.Fatalf("expected a deny with code %s before any rule ran, got %+v", canceledCode, d)
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultRequestIDHeader is the default API request header that request IDs
+// are taken from.
+const defaultRequestIDHeader = "X-Request-Id"
+
+// maxRequestIDLen is the longest request ID taken from a header. Longer IDs
+// are replaced with a generated one, so that clients can't flood the logs.
+const maxRequestIDLen = 128
+
+// requestIDHeader is the API request header that request IDs are taken from,
+// set from the command line. With an empty header, IDs are always generated.
+var requestIDHeader = defaultRequestIDHeader
+
+// headerRequestID returns the ID of the API request in req, for correlating
+// log lines, from its requestIDHeader header. ok is false if the request has
+// no usable ID, in which case one is generated with newUUID.
+func headerRequestID(req *authzReq) (id string, ok bool) {
+	if requestIDHeader == "" {
+		return "", false
+	}
+	id = strings.TrimSpace(http.Header(req.RequestHeader).Get(requestIDHeader))
+	if id == "" || len(id) > maxRequestIDLen || strings.IndexFunc(id, isControl) >= 0 {
+		return "", false
+	}
+	return id, true
+}
+
+// isControl returns true if r is a control character, with no place in a
+// request ID.
+func isControl(r rune) bool {
+	return r < 0x20 || r == 0x7f
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		// crypto/rand doesn't fail on the platforms the plugin runs on.
+		panic(err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
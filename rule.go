@@ -0,0 +1,402 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// rule is a single check that an authorization request is evaluated against.
+//
+// Each rule lives in its own rule_*.go file, and is added to ruleRegistry so
+// that it can be enabled, disabled, and configured by name through the policy
+// file. Rule settings are decoded from the policy file directly into the
+// value returned by the rule's constructor, so any exported fields on the rule
+// with a json tag are configurable.
+type rule interface {
+	// Name returns the name of the rule as it appears in the policy file.
+	Name() string
+
+	// Code returns the stable reason code reported when the rule denies a
+	// request, ie: DUH-USERNS-HOST. Codes must be unique and should never
+	// change once published, so that denies can be grouped by code
+	// regardless of changes to message wording.
+	Code() string
+
+	// Evaluate checks the request in ctx, returning a decision on whether or
+	// not the request should be allowed.
+	Evaluate(ctx *evalContext) decision
+
+	// options returns the settings common to all rules. This is satisfied by
+	// embedding ruleOptions.
+	options() *ruleOptions
+}
+
+// validator is implemented by rules that need to check or pre-process their
+// settings after they have been loaded from the policy file.
+type validator interface {
+	validate() error
+}
+
+// Authorization phases, for ruleOptions.Phases: the daemon asks the plugin
+// about a request before acting on it (AuthZReq), and again with the
+// response after acting on it (AuthZRes).
+const (
+	phaseReq = "req"
+	phaseRes = "res"
+)
+
+// Rule actions, for ruleOptions.Action.
+const (
+	actionDeny  = "deny"
+	actionWarn  = "warn"
+	actionAllow = "allow"
+)
+
+// ruleOptions are the settings common to all rules.
+type ruleOptions struct {
+	// Enabled controls whether or not the rule is evaluated.
+	Enabled bool `json:"enabled"`
+
+	// Action is what happens when the rule denies a request: deny (the
+	// default) denies it, warn allows it with a warning logged, and allow
+	// allows it silently.
+	Action string `json:"action"`
+
+	// Methods limits the rule to requests with these HTTP methods, ie: POST.
+	// An empty list means any method.
+	Methods []string `json:"methods"`
+
+	// Endpoints limits the rule to API endpoints matching these glob
+	// patterns, ie: /containers/*/exec, matched against the request path
+	// without the API version or query string. An empty list means any
+	// endpoint.
+	Endpoints []string `json:"endpoints"`
+
+	// Phases are the authorization phases the rule is enforced in, req
+	// and/or res. An empty list means req only, as denying a response after
+	// the daemon has already acted on the request leaves things in a
+	// confusing state.
+	Phases []string `json:"phases"`
+}
+
+// options implements rule for any struct embedding ruleOptions.
+func (o *ruleOptions) options() *ruleOptions {
+	return o
+}
+
+// inScope returns true if the request in ctx is in the scope set by Phases,
+// Methods, and Endpoints. Scope can only narrow the requests a rule applies
+// to: rules that check container creation still only look at
+// /containers/create.
+func (o *ruleOptions) inScope(ctx *evalContext) bool {
+	phases := o.Phases
+	if len(phases) == 0 {
+		phases = []string{phaseReq}
+	}
+	if !inList(phases, ctx.phase()) {
+		return false
+	}
+	if len(o.Methods) > 0 {
+		var ok bool
+		for _, m := range o.Methods {
+			if strings.EqualFold(m, ctx.req.RequestMethod) {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return false
+		}
+	}
+	return len(o.Endpoints) == 0 || matchAny(o.Endpoints, ctx.path())
+}
+
+// ruleRegistry is the list of constructors for all rules available to the
+// policy, in the order that they are evaluated. Constructors return the rule
+// with its default settings.
+var ruleRegistry = []func() rule{
+	newRequireAuthRule,
+	newUsernsRule,
+	newPrivilegedRule,
+	newNoNewPrivilegesRule,
+	newBindsRule,
+	newSysctlsRule,
+	newShmSizeRule,
+	newDockerSocketRule,
+	newPluginSocketRule,
+	newVolumesFromRule,
+	newReadonlyRootfsRule,
+	newOomKillDisableRule,
+	newOomScoreAdjRule,
+	newUlimitsRule,
+	newConditionsRule,
+	newCgroupParentRule,
+	newCapabilitiesRule,
+	newPidsLimitRule,
+	newMemoryRule,
+	newCPURule,
+	newBlkioRule,
+	newHostPortsRule,
+	newPublishAllPortsRule,
+	newDeviceCgroupRulesRule,
+	newExtraHostsRule,
+	newDNSRule,
+	newLogDriverRule,
+	newRestartPolicyRule,
+	newStorageOptRule,
+	newTmpfsRule,
+	newMaskedPathsRule,
+	newRuntimeRule,
+	newCommandRule,
+	newLinksRule,
+	newHostDevRule,
+	newContainerNamespacesRule,
+	newNonRootUserRule,
+	newSecretEnvRule,
+	newImageRegistryRule,
+	newPrivilegedLimitsRule,
+	newImagePinningRule,
+	newRequiredLabelsRule,
+	newNetworkCreateRule,
+	newContainerNameRule,
+	newHealthcheckRule,
+	newInitRule,
+	newGroupAddRule,
+	newLimitsRule,
+	newPlatformRule,
+	newMACAddressRule,
+	newExecPrivilegedRule,
+	newExecRootRule,
+	newCommitRule,
+	newBuildRule,
+	newImagesRule,
+	newBuildRemoteRule,
+	newPluginsRule,
+	newCapScoreRule,
+	newServicesRule,
+}
+
+func init() {
+	names := make(map[string]bool)
+	codes := make(map[string]string)
+	for _, newRule := range ruleRegistry {
+		r := newRule()
+		if names[r.Name()] {
+			panic(fmt.Sprintf("duplicate rule name %q", r.Name()))
+		}
+		if name, ok := codes[r.Code()]; ok {
+			panic(fmt.Sprintf("rule %q has the same code as %q: %s", r.Name(), name, r.Code()))
+		}
+		names[r.Name()] = true
+		codes[r.Code()] = r.Name()
+	}
+}
+
+// decision is the result of evaluating a rule.
+type decision struct {
+	// Allow is true if the rule allows the request.
+	Allow bool
+
+	// Msg is the reason the request was denied.
+	Msg string
+
+	// Code is the reason code of the rule that denied the request.
+	Code string
+
+	// Warnings are the reason codes of the rules with the warn action that
+	// would have denied the request. Only set by policy.evaluate.
+	Warnings []string
+}
+
+// allow returns a decision allowing the request.
+func allow() decision {
+	return decision{Allow: true}
+}
+
+// deny returns a decision denying the request, with a formatted message.
+func deny(format string, a ...interface{}) decision {
+	return decision{Msg: fmt.Sprintf(format, a...)}
+}
+
+// evalContext holds the request data that rules are evaluated against.
+type evalContext struct {
+	// The authorization request from the Docker daemon.
+	req *authzReq
+
+	// The decoded original API request body. This is empty if the request did
+	// not have a body.
+	body map[string]interface{}
+
+	// Extra data to include in the log line for the request. Rules can add to
+	// this to record details about a decision for auditing.
+	logData map[string]interface{}
+
+	// Whether this is the response phase (AuthZRes) of the request, rather
+	// than the request phase (AuthZReq).
+	response bool
+
+	// The logger for the request, which tags log lines with the request ID.
+	// The standard logger is used if nil.
+	log *log.Entry
+
+	// The context of the authorization request, which is canceled if the
+	// daemon gives up on it. context.Background is used if nil.
+	reqContext context.Context
+}
+
+// context returns the context of the request, for anything that can block,
+// such as network calls.
+func (c *evalContext) context() context.Context {
+	if c.reqContext == nil {
+		return context.Background()
+	}
+	return c.reqContext
+}
+
+// phase returns the authorization phase of the request, req or res.
+func (c *evalContext) phase() string {
+	if c.response {
+		return phaseRes
+	}
+	return phaseReq
+}
+
+// logger returns the logger for the request.
+func (c *evalContext) logger() *log.Entry {
+	if c.log == nil {
+		return log.NewEntry(log.StandardLogger())
+	}
+	return c.log
+}
+
+// hostConfig returns the HostConfig section of the request body, or nil if
+// there is none.
+func (c *evalContext) hostConfig() map[string]interface{} {
+	return getMap(c.body, "HostConfig")
+}
+
+// image returns the Image field of the request body.
+func (c *evalContext) image() string {
+	return getString(c.body, "Image")
+}
+
+// hasLabel returns true if the container being created has any of the
+// supplied labels set to the matching value.
+func (c *evalContext) hasLabel(labels map[string]string) bool {
+	l := getMap(c.body, "Labels")
+	for k, v := range labels {
+		if s, ok := l[k].(string); ok && s == v {
+			return true
+		}
+	}
+	return false
+}
+
+// path returns the API endpoint path of the request.
+func (c *evalContext) path() string {
+	return apiPath(c.req.RequestURI)
+}
+
+// query returns the parsed query string of the request URI, which is empty if
+// there is none, or it can't be parsed.
+func (c *evalContext) query() url.Values {
+	u, err := url.ParseRequestURI(c.req.RequestURI)
+	if err != nil {
+		return url.Values{}
+	}
+	return u.Query()
+}
+
+// isContainerCreate returns true if the request is for /containers/create.
+func (c *evalContext) isContainerCreate() bool {
+	return c.path() == "/containers/create"
+}
+
+// isBuild returns true if the request is for /build, an image build. The
+// request body of a build is the build context as a tar stream, never JSON.
+func (c *evalContext) isBuild() bool {
+	return c.req.RequestMethod == "POST" && c.path() == "/build"
+}
+
+// execContainer returns the ID or name of the container that the request
+// creates an exec instance in, for POST /containers/{id}/exec. ok is false for
+// other requests.
+func (c *evalContext) execContainer() (id string, ok bool) {
+	return c.containerEndpoint("exec")
+}
+
+// updateContainer returns the ID or name of the container that the request
+// updates the resources of, for POST /containers/{id}/update. ok is false for
+// other requests. The request body is the bare resources, rather than a
+// HostConfig in a container config.
+func (c *evalContext) updateContainer() (id string, ok bool) {
+	return c.containerEndpoint("update")
+}
+
+// containerEndpoint returns the ID or name of the container in a request for
+// POST /containers/{id}/<action>.
+func (c *evalContext) containerEndpoint(action string) (id string, ok bool) {
+	parts := strings.Split(c.path(), "/")
+	if c.req.RequestMethod != "POST" || len(parts) != 4 || parts[0] != "" || parts[1] != "containers" || parts[2] == "" || parts[3] != action {
+		return "", false
+	}
+	return parts[2], true
+}
+
+// apiPath normalizes a Docker API request URI to its endpoint path, by
+// removing the query string and any API version prefix. ie:
+// /v1.41/containers/create?name=foo becomes /containers/create.
+func apiPath(uri string) string {
+	if u, err := url.ParseRequestURI(uri); err == nil {
+		uri = u.Path
+	} else if i := strings.IndexAny(uri, "?#"); i >= 0 {
+		uri = uri[:i]
+	}
+	if strings.HasPrefix(uri, "/v") {
+		i := strings.Index(uri[1:], "/") + 1
+		if i == 0 {
+			i = len(uri)
+		}
+		if v := uri[2:i]; v != "" && strings.Trim(v, "0123456789.") == "" {
+			uri = uri[i:]
+		}
+	}
+	return uri
+}
+
+// toInt64 converts a JSON number decoded into an interface{} to an int64. ok
+// is false if v is not a number, or is not a whole number that fits in an
+// int64.
+func toInt64(v interface{}) (n int64, ok bool) {
+	f, ok := v.(float64)
+	if !ok || f != float64(int64(f)) {
+		return 0, false
+	}
+	return int64(f), true
+}
+
+// matchAny returns true if s matches any of the supplied glob patterns, using
+// the syntax of path.Match. Malformed patterns never match.
+func matchAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, s); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// inList returns true if s is in list.
+func inList(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
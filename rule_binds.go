@@ -0,0 +1,50 @@
+package main
+
+// bindsRule denies container creation when HostConfig.Binds or
+// HostConfig.Mounts contains a mount of a sensitive host path.
+type bindsRule struct {
+	ruleOptions
+
+	// Deny is a list of host paths that are not allowed to be bind mounted
+	// into a container, either directly or via a sub-path. The root path "/"
+	// only matches a mount of the root itself.
+	Deny []string `json:"deny"`
+}
+
+// defaultDenyBinds is the default Deny of bindsRule, which other rules
+// checking mounts also deny by default. /dev is covered by hostDevRule, which
+// can allow individual devices.
+var defaultDenyBinds = []string{"/", "/etc", "/var/run", "/proc", "/sys", "/boot"}
+
+// newBindsRule returns a bindsRule with its default settings.
+func newBindsRule() rule {
+	return &bindsRule{
+		ruleOptions: ruleOptions{Enabled: true},
+		Deny:        append([]string(nil), defaultDenyBinds...),
+	}
+}
+
+// Name implements rule for bindsRule.
+func (r *bindsRule) Name() string {
+	return "binds"
+}
+
+// Code implements rule for bindsRule.
+func (r *bindsRule) Code() string {
+	return "DUH-BIND-SOURCE"
+}
+
+// Evaluate implements rule for bindsRule.
+func (r *bindsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	for _, src := range ctx.hostSources() {
+		for _, p := range r.Deny {
+			if pathHasPrefix(src, p) {
+				return deny("bind mount of host path %s is not allowed", src)
+			}
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,105 @@
+package main
+
+import "fmt"
+
+// blkioThrottleFields are the HostConfig fields holding block IO device
+// throttles.
+var blkioThrottleFields = []string{
+	"BlkioDeviceReadBps",
+	"BlkioDeviceWriteBps",
+	"BlkioDeviceReadIOps",
+	"BlkioDeviceWriteIOps",
+}
+
+// blkioRule denies container creation when HostConfig.BlkioWeight is outside
+// of a range, and can require that at least one block IO device throttle is
+// set.
+type blkioRule struct {
+	ruleOptions
+
+	// MinWeight and MaxWeight are the bounds for BlkioWeight. Zero disables
+	// either bound. An unset weight is always allowed.
+	MinWeight int64 `json:"min-weight"`
+	MaxWeight int64 `json:"max-weight"`
+
+	// RequireThrottle denies containers without at least one device read or
+	// write throttle.
+	RequireThrottle bool `json:"require-throttle"`
+}
+
+// blkioThrottle is an entry in one of the block IO device throttle fields.
+type blkioThrottle struct {
+	Path string
+	Rate int64
+}
+
+// newBlkioRule returns a blkioRule with its default settings.
+func newBlkioRule() rule {
+	return &blkioRule{}
+}
+
+// Name implements rule for blkioRule.
+func (r *blkioRule) Name() string {
+	return "blkio"
+}
+
+// Code implements rule for blkioRule.
+func (r *blkioRule) Code() string {
+	return "DUH-BLKIO"
+}
+
+// Evaluate implements rule for blkioRule.
+func (r *blkioRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	if w, _ := toInt64(hc["BlkioWeight"]); w != 0 {
+		if r.MinWeight > 0 && w < r.MinWeight || r.MaxWeight > 0 && w > r.MaxWeight {
+			return deny("--blkio-weight=%d is outside of the allowed range of %d to %d", w, r.MinWeight, r.MaxWeight)
+		}
+	}
+	var throttled bool
+	for _, k := range blkioThrottleFields {
+		throttles, err := blkioThrottles(hc[k])
+		if err != nil {
+			return deny("malformed %s: %v", k, err)
+		}
+		if len(throttles) > 0 {
+			throttled = true
+		}
+	}
+	if r.RequireThrottle && !throttled {
+		return deny("a block IO throttle is required: add one of --device-read-bps, --device-write-bps, --device-read-iops, or --device-write-iops")
+	}
+	return allow()
+}
+
+// blkioThrottles decodes a block IO device throttle field. Entries must be
+// objects with a string Path and a non-negative whole number Rate.
+func blkioThrottles(v interface{}) ([]blkioThrottle, error) {
+	if v == nil {
+		return nil, nil
+	}
+	l, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected a list, got %T", v)
+	}
+	var throttles []blkioThrottle
+	for i, v := range l {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("entry %d: expected an object, got %T", i, v)
+		}
+		path, ok := m["Path"].(string)
+		if !ok || path == "" {
+			return nil, fmt.Errorf("entry %d: missing Path", i)
+		}
+		rate, ok := toInt64(m["Rate"])
+		if !ok || rate < 0 {
+			return nil, fmt.Errorf("entry %d: invalid Rate %v", i, m["Rate"])
+		}
+		throttles = append(throttles, blkioThrottle{Path: path, Rate: rate})
+	}
+	return throttles, nil
+}
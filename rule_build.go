@@ -0,0 +1,65 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// buildRule denies image builds with host networking or other risky build
+// options. Build options are all in the query string of /build, as the body
+// is the build context tar stream.
+type buildRule struct {
+	ruleOptions
+
+	// DenyNetworkModes is a list of glob patterns for network modes that
+	// build steps may not use, ie: host or container:*.
+	DenyNetworkModes []string `json:"deny-network-modes"`
+
+	// DenyParams is a list of /build query parameters that may not be set,
+	// ie: squash or cgroupparent. Parameters set to an empty string, 0,
+	// false, or an empty JSON value ([], {}, or null) count as not set, as
+	// clients send some of them with every build.
+	DenyParams []string `json:"deny-params"`
+}
+
+// newBuildRule returns a buildRule with its default settings.
+func newBuildRule() rule {
+	return &buildRule{
+		DenyNetworkModes: []string{"host"},
+	}
+}
+
+// Name implements rule for buildRule.
+func (r *buildRule) Name() string {
+	return "build"
+}
+
+// Code implements rule for buildRule.
+func (r *buildRule) Code() string {
+	return "DUH-BUILD"
+}
+
+// Evaluate implements rule for buildRule.
+func (r *buildRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isBuild() {
+		return allow()
+	}
+	q := ctx.query()
+	if mode := q.Get("networkmode"); mode != "" && matchAny(r.DenyNetworkModes, mode) {
+		ctx.logData["NetworkMode"] = mode
+		return deny("docker build --network=%s is not allowed", mode)
+	}
+	var denied []string
+	for _, p := range r.DenyParams {
+		switch v := strings.ToLower(q.Get(p)); v {
+		case "", "0", "false", "[]", "{}", "null":
+		default:
+			denied = append(denied, p)
+		}
+	}
+	if len(denied) == 0 {
+		return allow()
+	}
+	sort.Strings(denied)
+	return deny("docker build options %s are not allowed", strings.Join(denied, ", "))
+}
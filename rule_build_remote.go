@@ -0,0 +1,51 @@
+package main
+
+import "strings"
+
+// buildRemoteRule denies image builds from a remote build context, which
+// makes the daemon fetch the context from a URL given in the remote query
+// parameter of /build.
+type buildRemoteRule struct {
+	ruleOptions
+
+	// AllowPrefixes is a list of URL prefixes that remote build contexts are
+	// still allowed from, ie: https://git.example.com/.
+	AllowPrefixes []string `json:"allow-prefixes"`
+}
+
+// newBuildRemoteRule returns a buildRemoteRule with its default settings.
+func newBuildRemoteRule() rule {
+	return &buildRemoteRule{}
+}
+
+// Name implements rule for buildRemoteRule.
+func (r *buildRemoteRule) Name() string {
+	return "build-remote"
+}
+
+// Code implements rule for buildRemoteRule.
+func (r *buildRemoteRule) Code() string {
+	return "DUH-BUILD-REMOTE"
+}
+
+// Evaluate implements rule for buildRemoteRule.
+func (r *buildRemoteRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isBuild() {
+		return allow()
+	}
+	q := ctx.query()
+	if dockerfile := q.Get("dockerfile"); dockerfile != "" {
+		ctx.logData["Dockerfile"] = dockerfile
+	}
+	remote := q.Get("remote")
+	if remote == "" {
+		return allow()
+	}
+	ctx.logData["Remote"] = remote
+	for _, p := range r.AllowPrefixes {
+		if strings.HasPrefix(remote, p) {
+			return allow()
+		}
+	}
+	return deny("docker build from remote context %s is not allowed", remote)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// capScoreRule denies container creation when the capabilities a container
+// gets on top of the default set add up to more than a maximum score, using a
+// weight for each capability. This allows a few low-risk capabilities while
+// still denying combinations that approach --privileged.
+type capScoreRule struct {
+	ruleOptions
+
+	// Weights is the weight of each capability, merged with
+	// defaultCapWeights, so that only the weights to change need to be given.
+	// Names are case-insensitive, and may have the CAP_ prefix.
+	Weights map[string]int `json:"weights"`
+
+	// DefaultWeight is the weight of capabilities not in Weights.
+	DefaultWeight int `json:"default-weight"`
+
+	// MaxScore is the highest total weight allowed.
+	MaxScore int `json:"max-score"`
+
+	weights map[string]int
+}
+
+// defaultCapWeights is the weight of each capability that Weights is merged
+// with. Capabilities that are as good as root on the host on their own weigh
+// 10, and so are denied alone with the default MaxScore of 9.
+var defaultCapWeights = map[string]int{
+	"AUDIT_CONTROL":      3,
+	"AUDIT_READ":         1,
+	"BLOCK_SUSPEND":      1,
+	"BPF":                10,
+	"CHECKPOINT_RESTORE": 4,
+	"DAC_READ_SEARCH":    7,
+	"IPC_LOCK":           2,
+	"IPC_OWNER":          3,
+	"LEASE":              1,
+	"LINUX_IMMUTABLE":    3,
+	"MAC_ADMIN":          10,
+	"MAC_OVERRIDE":       10,
+	"NET_ADMIN":          5,
+	"NET_BROADCAST":      1,
+	"PERFMON":            4,
+	"SYSLOG":             3,
+	"SYS_ADMIN":          10,
+	"SYS_BOOT":           6,
+	"SYS_MODULE":         10,
+	"SYS_NICE":           2,
+	"SYS_PACCT":          2,
+	"SYS_PTRACE":         7,
+	"SYS_RAWIO":          10,
+	"SYS_RESOURCE":       3,
+	"SYS_TIME":           4,
+	"SYS_TTY_CONFIG":     2,
+	"WAKE_ALARM":         1,
+}
+
+// newCapScoreRule returns a capScoreRule with its default settings.
+func newCapScoreRule() rule {
+	return &capScoreRule{
+		DefaultWeight: 5,
+		MaxScore:      9,
+	}
+}
+
+// Name implements rule for capScoreRule.
+func (r *capScoreRule) Name() string {
+	return "cap-score"
+}
+
+// Code implements rule for capScoreRule.
+func (r *capScoreRule) Code() string {
+	return "DUH-CAP-SCORE"
+}
+
+// validate implements validator for capScoreRule.
+func (r *capScoreRule) validate() error {
+	r.weights = make(map[string]int)
+	for c, w := range defaultCapWeights {
+		r.weights[c] = w
+	}
+	// Names given with the CAP_ prefix or in lower case take precedence over
+	// the same capability given normalized, so the result doesn't depend on
+	// map order.
+	for _, normalized := range []bool{true, false} {
+		for c, w := range r.Weights {
+			if w < 0 {
+				return fmt.Errorf("weights: %s has a negative weight", c)
+			}
+			if (normalizeCap(c) == c) == normalized {
+				r.weights[normalizeCap(c)] = w
+			}
+		}
+	}
+	return nil
+}
+
+// Evaluate implements rule for capScoreRule.
+func (r *capScoreRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	defaults := make(map[string]bool)
+	for _, c := range defaultCaps {
+		defaults[c] = true
+	}
+	var score int
+	var added []string
+	for _, c := range effectiveCaps(toStrings(hc["CapAdd"]), toStrings(hc["CapDrop"])) {
+		if defaults[c] {
+			continue
+		}
+		w, ok := r.weights[c]
+		if !ok {
+			w = r.DefaultWeight
+		}
+		score += w
+		added = append(added, fmt.Sprintf("%s=%d", c, w))
+	}
+	ctx.logData["CapScore"] = score
+	if score <= r.MaxScore {
+		return allow()
+	}
+	sort.Strings(added)
+	return deny("added capabilities score %d, more than the maximum of %d: %s", score, r.MaxScore, strings.Join(added, ", "))
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestCapScoreRule(t *testing.T) {
+	caps := func(add ...interface{}) authzReq {
+		return newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": arr(add)}))
+	}
+	runRuleCases(t, testPolicy(t, "cap-score.enabled=true"), []ruleCase{
+		{"no caps", caps(), true, ""},
+		{"default cap", caps("CHOWN", "CAP_KILL"), true, ""},
+		{"low score", caps("NET_BROADCAST", "cap_sys_nice", "LEASE"), true, ""},
+		{"at max", caps("NET_ADMIN", "PERFMON"), true, ""},
+		{"over max", caps("NET_ADMIN", "PERFMON", "LEASE"), false, "added capabilities score 10, more than the maximum of 9: LEASE=1, NET_ADMIN=5, PERFMON=4"},
+		{"root alone", caps("SYS_ADMIN"), false, "added capabilities score 10, more than the maximum of 9: SYS_ADMIN=10"},
+		{"unknown cap", caps("NET_BIND_SERVICE", "FUTURE_CAP"), true, ""},
+		{"all", caps("ALL"), false, ""},
+		{"drop all then add", newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": arr{"SYS_ADMIN"}, "CapDrop": arr{"ALL"}})), false, "added capabilities score 10, more than the maximum of 9: SYS_ADMIN=10"},
+	})
+}
+
+// TestCapScoreWeightsMerged checks that weights given in the policy file, with
+// -set, or in the environment are merged with the default weights rather than
+// replacing them.
+func TestCapScoreWeightsMerged(t *testing.T) {
+	cases := []ruleCase{
+		{"overridden weight", newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": arr{"NET_ADMIN", "PERFMON", "LEASE"}})), true, ""},
+		{"default weight kept", newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": arr{"NET_ADMIN", "SYS_ADMIN"}})), false, "added capabilities score 11, more than the maximum of 9: NET_ADMIN=1, SYS_ADMIN=10"},
+	}
+	t.Run("policy file", func(t *testing.T) {
+		runRuleCases(t, testPolicyFile(t, `{"rules": {"cap-score": {"enabled": true, "weights": {"NET_ADMIN": 1}}}}`), cases)
+	})
+	t.Run("set", func(t *testing.T) {
+		runRuleCases(t, testPolicy(t, "cap-score.enabled=true", `cap-score.weights={"CAP_NET_ADMIN": 1}`), cases)
+	})
+	t.Run("environment", func(t *testing.T) {
+		t.Setenv("DUH_CAP_SCORE_ENABLED", "true")
+		t.Setenv("DUH_CAP_SCORE_WEIGHTS", `{"net_admin": 1}`)
+		p, err := loadPolicy("", envSettings())
+		if err != nil {
+			t.Fatal(err)
+		}
+		runRuleCases(t, p, cases)
+	})
+}
@@ -0,0 +1,80 @@
+package main
+
+import "strings"
+
+// capabilitiesRule denies container creation when the effective capability
+// set of the container, after applying HostConfig.CapDrop and then
+// HostConfig.CapAdd to the default set, contains a denied capability.
+//
+// Checking the effective set rather than just CapAdd means that
+// --cap-drop ALL --cap-add SYS_ADMIN is caught just like --cap-add SYS_ADMIN.
+type capabilitiesRule struct {
+	ruleOptions
+
+	// Deny is the list of capabilities that containers may not have. Names
+	// are case-insensitive, and may have the CAP_ prefix.
+	Deny []string `json:"deny"`
+}
+
+// defaultDenyCaps is the default Deny of capabilitiesRule, which other rules
+// checking capabilities also deny by default.
+var defaultDenyCaps = []string{
+	"DAC_READ_SEARCH",
+	"NET_ADMIN",
+	"SYS_ADMIN",
+	"SYS_BOOT",
+	"SYS_MODULE",
+	"SYS_PTRACE",
+	"SYS_RAWIO",
+	"SYS_TIME",
+}
+
+// newCapabilitiesRule returns a capabilitiesRule with its default settings.
+func newCapabilitiesRule() rule {
+	return &capabilitiesRule{
+		Deny: append([]string(nil), defaultDenyCaps...),
+	}
+}
+
+// Name implements rule for capabilitiesRule.
+func (r *capabilitiesRule) Name() string {
+	return "capabilities"
+}
+
+// Code implements rule for capabilitiesRule.
+func (r *capabilitiesRule) Code() string {
+	return "DUH-CAPABILITY"
+}
+
+// Evaluate implements rule for capabilitiesRule.
+func (r *capabilitiesRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	// --cap-add ALL is as good as --privileged as far as capabilities go, so
+	// it is denied whatever is in Deny.
+	for _, c := range toStrings(hc["CapAdd"]) {
+		if normalizeCap(c) == "ALL" {
+			return deny("CapAdd ALL is not allowed")
+		}
+	}
+	denied := make(map[string]bool)
+	for _, c := range r.Deny {
+		denied[normalizeCap(c)] = true
+	}
+	var caps []string
+	for _, c := range effectiveCaps(toStrings(hc["CapAdd"]), toStrings(hc["CapDrop"])) {
+		if denied[c] {
+			caps = append(caps, c)
+		}
+	}
+	switch len(caps) {
+	case 0:
+		return allow()
+	case 1:
+		return deny("capability %s is not allowed", caps[0])
+	default:
+		return deny("capabilities %s are not allowed", strings.Join(caps, ", "))
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// capsReq returns a container create request with CapAdd and CapDrop.
+func capsReq(add, drop arr) authzReq {
+	return newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": add, "CapDrop": drop}))
+}
+
+func TestCapabilitiesRule(t *testing.T) {
+	runRuleCases(t, testPolicy(t, "capabilities.enabled=true"), []ruleCase{
+		{"defaults", capsReq(nil, nil), true, ""},
+		{"allowed add", capsReq(arr{"NET_BIND_SERVICE", "SYS_NICE"}, nil), true, ""},
+		{"plain add", capsReq(arr{"SYS_ADMIN"}, nil), false, "capability SYS_ADMIN is not allowed"},
+		{"prefixed lower case", capsReq(arr{"cap_sys_ptrace"}, nil), false, "capability SYS_PTRACE is not allowed"},
+		{"several", capsReq(arr{"SYS_TIME", "NET_ADMIN", "CHOWN"}, nil), false, "capabilities NET_ADMIN, SYS_TIME are not allowed"},
+		{"drop all then add", capsReq(arr{"SYS_ADMIN"}, arr{"ALL"}), false, "capability SYS_ADMIN is not allowed"},
+		{"drop all then add allowed", capsReq(arr{"NET_BIND_SERVICE"}, arr{"ALL"}), true, ""},
+		{"drop all", capsReq(nil, arr{"ALL"}), true, ""},
+		{"drop then add same", capsReq(arr{"SYS_ADMIN"}, arr{"SYS_ADMIN"}), false, ""},
+		{"add ALL", capsReq(arr{"ALL"}, nil), false, "CapAdd ALL is not allowed"},
+		{"add all lower case", capsReq(arr{"all"}, nil), false, "CapAdd ALL is not allowed"},
+		{"add CAP_ALL", capsReq(arr{"NET_BIND_SERVICE", "CAP_ALL"}, nil), false, "CapAdd ALL is not allowed"},
+		{"add ALL after drop ALL", capsReq(arr{" ALL "}, arr{"ALL"}), false, "CapAdd ALL is not allowed"},
+		{"not a list", newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": "SYS_ADMIN"})), true, ""},
+	})
+	runRuleCases(t, testPolicy(t, "capabilities.enabled=true", "capabilities.deny=chown"), []ruleCase{
+		{"add ALL with other deny list", capsReq(arr{"ALL"}, nil), false, "CapAdd ALL is not allowed"},
+		{"default cap denied", capsReq(nil, nil), false, "capability CHOWN is not allowed"},
+		{"default cap dropped", capsReq(nil, arr{"CAP_CHOWN"}), true, ""},
+	})
+}
+
+func TestEffectiveCaps(t *testing.T) {
+	cases := []struct {
+		name      string
+		add, drop []string
+		want      []string
+	}{
+		{"drop all", nil, []string{"ALL"}, nil},
+		{"drop all then add", []string{"sys_admin", "CAP_NET_RAW"}, []string{"all"}, []string{"NET_RAW", "SYS_ADMIN"}},
+		{"add all", []string{"ALL"}, nil, allCaps},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := effectiveCaps(c.add, c.drop); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
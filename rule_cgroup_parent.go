@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// cgroupParentRule denies container creation when HostConfig.CgroupParent is
+// set to a cgroup outside of the allowed prefixes.
+type cgroupParentRule struct {
+	ruleOptions
+
+	// Allow is a list of allowed cgroup parent prefixes. Prefixes with a
+	// slash are cgroupfs paths, with or without the leading slash, and are
+	// matched on whole path components. All other prefixes are matched as a
+	// string prefix of systemd slice names (ie: docker- for
+	// docker-tenant.slice).
+	Allow []string `json:"allow"`
+
+	cgroupfs []string
+	slices   []string
+}
+
+// newCgroupParentRule returns a cgroupParentRule with its default settings.
+func newCgroupParentRule() rule {
+	return &cgroupParentRule{}
+}
+
+// Name implements rule for cgroupParentRule.
+func (r *cgroupParentRule) Name() string {
+	return "cgroup-parent"
+}
+
+// Code implements rule for cgroupParentRule.
+func (r *cgroupParentRule) Code() string {
+	return "DUH-CGROUP-PARENT"
+}
+
+// validate implements validator for cgroupParentRule.
+func (r *cgroupParentRule) validate() error {
+	r.cgroupfs, r.slices = nil, nil
+	for _, p := range r.Allow {
+		if strings.Contains(p, "/") {
+			r.cgroupfs = append(r.cgroupfs, path.Clean("/"+p))
+		} else {
+			r.slices = append(r.slices, p)
+		}
+	}
+	return nil
+}
+
+// Evaluate implements rule for cgroupParentRule.
+func (r *cgroupParentRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	v := getString(ctx.hostConfig(), "CgroupParent")
+	if v == "" || r.allowed(v) {
+		return allow()
+	}
+	ctx.logData["CgroupParent"] = v
+	return deny("--cgroup-parent %s is not allowed", v)
+}
+
+// allowed returns true if the cgroup parent v matches one of the allowed
+// prefixes.
+//
+// Docker treats parents ending in .slice as systemd slices when using the
+// systemd cgroup driver, and everything else as a cgroupfs path, which may or
+// may not have a leading slash.
+func (r *cgroupParentRule) allowed(v string) bool {
+	if strings.HasSuffix(v, ".slice") && !strings.Contains(v, "/") {
+		for _, p := range r.slices {
+			if strings.HasPrefix(v, p) {
+				return true
+			}
+		}
+		return false
+	}
+	cgroupfs := path.Clean("/" + v)
+	for _, p := range r.cgroupfs {
+		if pathHasPrefix(cgroupfs, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestCgroupParentRule(t *testing.T) {
+	parent := func(v string) authzReq {
+		return newAuthzReq("POST", "/containers/create", createBody(obj{"CgroupParent": v}))
+	}
+	p := testPolicy(t, "cgroup-parent.enabled=true", "cgroup-parent.allow=/docker,system.slice/tenants/,docker-")
+	runRuleCases(t, p, []ruleCase{
+		{"unset", parent(""), true, ""},
+		{"cgroupfs", parent("/docker/tenant-a"), true, ""},
+		{"cgroupfs without slash", parent("docker/tenant-a"), true, ""},
+		{"cgroupfs exact", parent("/docker"), true, ""},
+		{"cgroupfs escape", parent("/docker/../evil"), false, "--cgroup-parent /docker/../evil is not allowed"},
+		{"cgroupfs partial component", parent("/dockerevil"), false, ""},
+		{"prefix without slash", parent("system.slice/tenants/a"), true, ""},
+		{"prefix without slash, leading slash", parent("/system.slice/tenants/b"), true, ""},
+		{"prefix without slash, outside", parent("system.slice/other"), false, ""},
+		{"slice", parent("docker-tenant.slice"), true, ""},
+		{"other slice", parent("user.slice"), false, "--cgroup-parent user.slice is not allowed"},
+		{"slice prefix is not a path", parent("/docker-tenant"), false, ""},
+	})
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"path"
+	"strings"
+)
+
+// commandRule denies container creation when Entrypoint or Cmd starts with a
+// denied binary, such as tools used to escape into the host's namespaces.
+type commandRule struct {
+	ruleOptions
+	exemptions
+
+	// Deny is a list of glob patterns for denied binaries. Patterns are
+	// matched against both the first element of the command as given and
+	// its base name, so "nsenter" also matches "/usr/bin/nsenter".
+	Deny []string `json:"deny"`
+}
+
+// newCommandRule returns a commandRule with its default settings.
+func newCommandRule() rule {
+	return &commandRule{
+		Deny: []string{"nsenter", "unshare"},
+	}
+}
+
+// Name implements rule for commandRule.
+func (r *commandRule) Name() string {
+	return "command"
+}
+
+// Code implements rule for commandRule.
+func (r *commandRule) Code() string {
+	return "DUH-COMMAND"
+}
+
+// Evaluate implements rule for commandRule.
+func (r *commandRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	entrypoint := commandArgs(ctx.body["Entrypoint"])
+	cmd := commandArgs(ctx.body["Cmd"])
+	for _, args := range [][]string{entrypoint, cmd} {
+		if len(args) == 0 {
+			continue
+		}
+		if bin := args[0]; matchAny(r.Deny, bin) || matchAny(r.Deny, path.Base(bin)) {
+			ctx.logData["Command"] = strings.Join(append(entrypoint, cmd...), " ")
+			return deny("running %s is not allowed", bin)
+		}
+	}
+	return allow()
+}
+
+// commandArgs returns the arguments of an Entrypoint or Cmd field, which are
+// usually a list of strings, but can be a single string in older API
+// versions.
+func commandArgs(v interface{}) []string {
+	if s, ok := v.(string); ok {
+		return strings.Fields(s)
+	}
+	return toStrings(v)
+}
@@ -0,0 +1,50 @@
+package main
+
+import "strings"
+
+// commitRule denies docker commit, which turns a running container into an
+// image outside of the usual image provenance controls.
+type commitRule struct {
+	ruleOptions
+
+	// AllowRepos is a list of glob patterns for repositories that commits are
+	// allowed to, ie: registry.example.com/snapshots/*. An empty list denies
+	// every commit, and commits without a repository are only allowed by *.
+	AllowRepos []string `json:"allow-repos"`
+}
+
+// newCommitRule returns a commitRule with its default settings.
+func newCommitRule() rule {
+	return &commitRule{}
+}
+
+// Name implements rule for commitRule.
+func (r *commitRule) Name() string {
+	return "commit"
+}
+
+// Code implements rule for commitRule.
+func (r *commitRule) Code() string {
+	return "DUH-COMMIT"
+}
+
+// Evaluate implements rule for commitRule.
+func (r *commitRule) Evaluate(ctx *evalContext) decision {
+	if ctx.req.RequestMethod != "POST" || ctx.path() != "/commit" {
+		return allow()
+	}
+	q := ctx.query()
+	container, repo := q.Get("container"), q.Get("repo")
+	ctx.logData["Container"] = container
+	ctx.logData["Repo"] = repo
+	switch {
+	case len(r.AllowRepos) == 0:
+		return deny("docker commit is not allowed")
+	case !matchAny(r.AllowRepos, repo):
+		if repo == "" {
+			return deny("docker commit without a repository is not allowed, allowed repositories are: %s", strings.Join(r.AllowRepos, ", "))
+		}
+		return deny("docker commit to repository %s is not allowed, allowed repositories are: %s", repo, strings.Join(r.AllowRepos, ", "))
+	}
+	return allow()
+}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// conditionsRule denies container creation when the request matches any of a
+// list of configured boolean conditions. This allows policies that are
+// combinations of simpler checks (ie: privileged AND host networking) to be
+// written in the policy file without a dedicated rule.
+type conditionsRule struct {
+	ruleOptions
+
+	// Conditions is the list of conditions to check, in order.
+	Conditions []namedCondition `json:"conditions"`
+}
+
+// namedCondition is a condition with a name and message, reported when the
+// condition matches a request.
+type namedCondition struct {
+	condition
+
+	// The name of the condition, for logging.
+	Name string `json:"name"`
+
+	// The message sent back to the client when the condition matches.
+	Message string `json:"message"`
+}
+
+// condition is a node in a boolean expression. Exactly one of All, Any, or
+// Field must be set.
+//
+// All and Any match if all or any of their sub-conditions match,
+// respectively. Field makes the condition a leaf, which matches a single field
+// of the request using exactly one of Equals, Contains, Matches, or Exists.
+type condition struct {
+	All []condition `json:"all"`
+	Any []condition `json:"any"`
+
+	// The field to match, one of the keys in conditionFields, or a container
+	// label in the form Labels.<key>.
+	Field string `json:"field"`
+
+	// Equals matches if the field is equal to the value. For list fields, it
+	// matches if any element is equal to the value.
+	Equals interface{} `json:"equals"`
+
+	// Contains matches if the field contains the string. For list fields, it
+	// matches if any element contains the string.
+	Contains string `json:"contains"`
+
+	// Matches matches if the field matches the regular expression. For list
+	// fields, it matches if any element does.
+	Matches string `json:"matches"`
+
+	// Exists matches if the field is set when true, or if it is not set
+	// when false, ie: to match containers missing a label.
+	Exists *bool `json:"exists"`
+
+	// The compiled Matches expression.
+	re *regexp.Regexp
+}
+
+// conditionFields are the fields that conditions can match on, and functions
+// to fetch them from the request. Values are in the form returned by
+// encoding/json when decoding into an interface{}.
+var conditionFields = map[string]func(ctx *evalContext) interface{}{
+	"Image":                     bodyField("Image"),
+	"User":                      bodyField("User"),
+	"Env":                       bodyField("Env"),
+	"Cmd":                       bodyField("Cmd"),
+	"HostConfig.Privileged":     hostConfigField("Privileged"),
+	"HostConfig.NetworkMode":    hostConfigField("NetworkMode"),
+	"HostConfig.UsernsMode":     hostConfigField("UsernsMode"),
+	"HostConfig.Binds":          hostConfigField("Binds"),
+	"HostConfig.VolumesFrom":    hostConfigField("VolumesFrom"),
+	"HostConfig.SecurityOpt":    hostConfigField("SecurityOpt"),
+	"HostConfig.ShmSize":        hostConfigField("ShmSize"),
+	"HostConfig.ReadonlyRootfs": hostConfigField("ReadonlyRootfs"),
+	"HostConfig.OomKillDisable": hostConfigField("OomKillDisable"),
+	"HostConfig.Memory":         hostConfigField("Memory"),
+	"HostSources": func(ctx *evalContext) interface{} {
+		var l []interface{}
+		for _, v := range ctx.hostSources() {
+			l = append(l, v)
+		}
+		return l
+	},
+}
+
+// labelFieldPrefix is the prefix of condition fields that match the value of
+// a container label, ie: Labels.owner.
+const labelFieldPrefix = "Labels."
+
+// lookupConditionField returns the function fetching the named condition
+// field from the request.
+func lookupConditionField(name string) (func(ctx *evalContext) interface{}, bool) {
+	if key := strings.TrimPrefix(name, labelFieldPrefix); key != name && key != "" {
+		return func(ctx *evalContext) interface{} {
+			l := getMap(ctx.body, "Labels")
+			return l[key]
+		}, true
+	}
+	f, ok := conditionFields[name]
+	return f, ok
+}
+
+// bodyField returns a function fetching key from the request body, for
+// conditionFields.
+func bodyField(key string) func(ctx *evalContext) interface{} {
+	return func(ctx *evalContext) interface{} {
+		return ctx.body[key]
+	}
+}
+
+// hostConfigField returns a function fetching key from the request's
+// HostConfig, for conditionFields.
+func hostConfigField(key string) func(ctx *evalContext) interface{} {
+	return func(ctx *evalContext) interface{} {
+		return ctx.hostConfig()[key]
+	}
+}
+
+// newConditionsRule returns a conditionsRule with its default settings.
+func newConditionsRule() rule {
+	return &conditionsRule{}
+}
+
+// Name implements rule for conditionsRule.
+func (r *conditionsRule) Name() string {
+	return "conditions"
+}
+
+// Code implements rule for conditionsRule.
+func (r *conditionsRule) Code() string {
+	return "DUH-CONDITION"
+}
+
+// Evaluate implements rule for conditionsRule.
+func (r *conditionsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	for _, c := range r.Conditions {
+		if c.match(ctx) {
+			ctx.logData["Condition"] = c.Name
+			if labels := c.labels(ctx); len(labels) > 0 {
+				ctx.logData["Labels"] = labels
+			}
+			return deny("%s", c.Message)
+		}
+	}
+	return allow()
+}
+
+// validate implements validator for conditionsRule.
+func (r *conditionsRule) validate() error {
+	for i := range r.Conditions {
+		c := &r.Conditions[i]
+		if c.Name == "" {
+			return fmt.Errorf("condition %d has no name", i)
+		}
+		if c.Message == "" {
+			c.Message = fmt.Sprintf("request matches condition %s", c.Name)
+		}
+		if err := c.validate(); err != nil {
+			return fmt.Errorf("condition %s: %v", c.Name, err)
+		}
+	}
+	return nil
+}
+
+// validate checks that the condition and its sub-conditions are well formed,
+// and compiles any regular expressions.
+func (c *condition) validate() error {
+	var n int
+	for _, set := range []bool{len(c.All) > 0, len(c.Any) > 0, c.Field != ""} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("exactly one of all, any, or field must be set")
+	}
+	for _, l := range [][]condition{c.All, c.Any} {
+		for i := range l {
+			if err := l[i].validate(); err != nil {
+				return err
+			}
+		}
+	}
+	if c.Field == "" {
+		return nil
+	}
+	if _, ok := lookupConditionField(c.Field); !ok {
+		return fmt.Errorf("unknown field %q", c.Field)
+	}
+	n = 0
+	for _, set := range []bool{c.Equals != nil, c.Contains != "", c.Matches != "", c.Exists != nil} {
+		if set {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("field %s: exactly one of equals, contains, matches, or exists must be set", c.Field)
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return fmt.Errorf("field %s: %v", c.Field, err)
+		}
+		c.re = re
+	}
+	return nil
+}
+
+// match returns true if the request in ctx matches the condition.
+func (c *condition) match(ctx *evalContext) bool {
+	switch {
+	case len(c.All) > 0:
+		for i := range c.All {
+			if !c.All[i].match(ctx) {
+				return false
+			}
+		}
+		return true
+	case len(c.Any) > 0:
+		for i := range c.Any {
+			if c.Any[i].match(ctx) {
+				return true
+			}
+		}
+		return false
+	}
+	f, _ := lookupConditionField(c.Field)
+	v := f(ctx)
+	if c.Exists != nil {
+		return (v != nil) == *c.Exists
+	}
+	if l, ok := v.([]interface{}); ok {
+		for _, v := range l {
+			if c.matchValue(v) {
+				return true
+			}
+		}
+		return false
+	}
+	return c.matchValue(v)
+}
+
+// matchValue matches a single value with the leaf condition.
+func (c *condition) matchValue(v interface{}) bool {
+	if c.Equals != nil {
+		return reflect.DeepEqual(v, c.Equals)
+	}
+	s, ok := v.(string)
+	if !ok {
+		return false
+	}
+	if c.re != nil {
+		return c.re.MatchString(s)
+	}
+	return strings.Contains(s, c.Contains)
+}
+
+// labels returns the container labels that the condition and its
+// sub-conditions match on, with their values in the request. Labels that are
+// not set are left out.
+func (c *condition) labels(ctx *evalContext) map[string]interface{} {
+	m := make(map[string]interface{})
+	for _, l := range [][]condition{c.All, c.Any} {
+		for i := range l {
+			for k, v := range l[i].labels(ctx) {
+				m[k] = v
+			}
+		}
+	}
+	if key := strings.TrimPrefix(c.Field, labelFieldPrefix); key != c.Field {
+		if f, ok := lookupConditionField(c.Field); ok {
+			if v := f(ctx); v != nil {
+				m[key] = v
+			}
+		}
+	}
+	return m
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// containerNameRule denies container creation when the container name, given
+// in the name query parameter of the request, doesn't follow a naming
+// convention.
+type containerNameRule struct {
+	ruleOptions
+	exemptions
+
+	// Pattern is a regular expression that container names must match, ie:
+	// ^[a-z]+-[a-z0-9-]+$. An empty pattern allows any name.
+	Pattern string `json:"pattern"`
+
+	// RequireName denies containers created without a name.
+	RequireName bool `json:"require-name"`
+
+	pattern *regexp.Regexp
+}
+
+// newContainerNameRule returns a containerNameRule with its default settings.
+func newContainerNameRule() rule {
+	return &containerNameRule{}
+}
+
+// Name implements rule for containerNameRule.
+func (r *containerNameRule) Name() string {
+	return "container-name"
+}
+
+// Code implements rule for containerNameRule.
+func (r *containerNameRule) Code() string {
+	return "DUH-CONTAINER-NAME"
+}
+
+// validate implements validator for containerNameRule.
+func (r *containerNameRule) validate() error {
+	r.pattern = nil
+	if r.Pattern == "" {
+		return nil
+	}
+	var err error
+	if r.pattern, err = regexp.Compile(r.Pattern); err != nil {
+		return fmt.Errorf("pattern: %v", err)
+	}
+	return nil
+}
+
+// Evaluate implements rule for containerNameRule.
+func (r *containerNameRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	// The daemon accepts names with a leading slash, the form it uses
+	// internally.
+	name := strings.TrimPrefix(ctx.query().Get("name"), "/")
+	if name == "" {
+		if r.RequireName {
+			return deny("containers must be created with a name, use --name")
+		}
+		return allow()
+	}
+	ctx.logData["Name"] = name
+	if r.pattern != nil && !r.pattern.MatchString(name) {
+		return deny("container name %s does not match the naming convention %s", name, r.Pattern)
+	}
+	return allow()
+}
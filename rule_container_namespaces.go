@@ -0,0 +1,68 @@
+package main
+
+import "strings"
+
+// containerNamespacesRule denies container creation when PidMode, IpcMode, or
+// NetworkMode joins the namespace of another container, with the
+// "container:<name|id>" mode.
+type containerNamespacesRule struct {
+	ruleOptions
+
+	// Pid, Ipc, and Network choose which namespaces are checked.
+	Pid     bool `json:"pid"`
+	Ipc     bool `json:"ipc"`
+	Network bool `json:"network"`
+
+	// Allow is a list of glob patterns for the names or IDs of containers
+	// whose namespaces may be joined, ie: for sidecars.
+	Allow []string `json:"allow"`
+}
+
+// newContainerNamespacesRule returns a containerNamespacesRule with its
+// default settings.
+func newContainerNamespacesRule() rule {
+	return &containerNamespacesRule{
+		Pid:     true,
+		Ipc:     true,
+		Network: true,
+	}
+}
+
+// Name implements rule for containerNamespacesRule.
+func (r *containerNamespacesRule) Name() string {
+	return "container-namespaces"
+}
+
+// Code implements rule for containerNamespacesRule.
+func (r *containerNamespacesRule) Code() string {
+	return "DUH-CONTAINER-NAMESPACE"
+}
+
+// Evaluate implements rule for containerNamespacesRule.
+func (r *containerNamespacesRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	for _, ns := range []struct {
+		enabled bool
+		key     string
+		flag    string
+	}{
+		{r.Pid, "PidMode", "--pid"},
+		{r.Ipc, "IpcMode", "--ipc"},
+		{r.Network, "NetworkMode", "--network"},
+	} {
+		if !ns.enabled {
+			continue
+		}
+		mode := getString(hc, ns.key)
+		if !strings.HasPrefix(mode, "container:") {
+			continue
+		}
+		if target := strings.TrimPrefix(mode, "container:"); !matchAny(r.Allow, target) {
+			return deny("%s=%s is not allowed, as it joins the namespace of another container", ns.flag, mode)
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,94 @@
+package main
+
+// cpuRule denies container creation unless the container has a CPU limit,
+// optionally no larger than a maximum number of CPUs.
+//
+// --cpus is sent as either HostConfig.NanoCpus, or as HostConfig.CpuQuota
+// over HostConfig.CpuPeriod by older clients, so either encoding satisfies
+// the requirement.
+type cpuRule struct {
+	ruleOptions
+	exemptions
+
+	// AllowShares accepts HostConfig.CpuShares as a CPU limit. Shares are a
+	// relative weight that only applies when CPUs are contended, rather than a
+	// hard ceiling.
+	AllowShares bool `json:"allow-shares"`
+
+	// MaxCPUs is the largest allowed CPU limit, in CPUs. Zero allows any limit.
+	MaxCPUs float64 `json:"max-cpus"`
+}
+
+// defaultCPUPeriod is the CFS period used by the kernel (and Docker) when
+// HostConfig.CpuPeriod is not set, in microseconds.
+const defaultCPUPeriod = 100000
+
+// newCPURule returns a cpuRule with its default settings.
+func newCPURule() rule {
+	return &cpuRule{
+		AllowShares: true,
+	}
+}
+
+// Name implements rule for cpuRule.
+func (r *cpuRule) Name() string {
+	return "cpu"
+}
+
+// Code implements rule for cpuRule.
+func (r *cpuRule) Code() string {
+	return "DUH-CPU"
+}
+
+// Evaluate implements rule for cpuRule.
+func (r *cpuRule) Evaluate(ctx *evalContext) decision {
+	if id, ok := ctx.updateContainer(); ok {
+		return r.evaluateUpdate(ctx, id)
+	}
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	cpus := cpuLimit(hc)
+	if cpus == 0 {
+		if shares, _ := toInt64(hc["CpuShares"]); r.AllowShares && shares > 0 {
+			return allow()
+		}
+		return deny("a CPU limit is required: add --cpus")
+	}
+	if r.MaxCPUs > 0 && cpus > r.MaxCPUs {
+		return deny("--cpus of %g exceeds the maximum of %g", cpus, r.MaxCPUs)
+	}
+	return allow()
+}
+
+// evaluateUpdate checks a docker update of container id. Fields that are zero
+// leave the current limits unchanged, and a CpuQuota of -1 removes the limit.
+func (r *cpuRule) evaluateUpdate(ctx *evalContext, id string) decision {
+	ctx.logData["ContainerID"] = id
+	if quota, _ := toInt64(ctx.body["CpuQuota"]); quota < 0 {
+		return deny("removing the CPU limit is not allowed")
+	}
+	if cpus := cpuLimit(ctx.body); r.MaxCPUs > 0 && cpus > r.MaxCPUs {
+		return deny("--cpus of %g exceeds the maximum of %g", cpus, r.MaxCPUs)
+	}
+	return allow()
+}
+
+// cpuLimit returns the CPU limit in hc, in CPUs, from either NanoCpus or
+// CpuQuota and CpuPeriod. Zero means no limit. hc can also be the resources
+// of a docker update.
+func cpuLimit(hc map[string]interface{}) float64 {
+	if n, _ := toInt64(hc["NanoCpus"]); n > 0 {
+		return float64(n) / 1e9
+	}
+	quota, _ := toInt64(hc["CpuQuota"])
+	if quota <= 0 {
+		return 0
+	}
+	period, _ := toInt64(hc["CpuPeriod"])
+	if period <= 0 {
+		period = defaultCPUPeriod
+	}
+	return float64(quota) / float64(period)
+}
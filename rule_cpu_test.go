@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestCPURule(t *testing.T) {
+	create := func(hc obj) authzReq {
+		return newAuthzReq("POST", "/containers/create", createBody(hc))
+	}
+	p := testPolicy(t, "cpu.enabled=true", "cpu.max-cpus=2")
+	runRuleCases(t, p, []ruleCase{
+		{"no limit", create(nil), false, "a CPU limit is required: add --cpus"},
+		{"nano cpus", create(obj{"NanoCpus": 1500000000}), true, ""},
+		{"nano cpus over max", create(obj{"NanoCpus": 4000000000}), false, "--cpus of 4 exceeds the maximum of 2"},
+		{"quota", create(obj{"CpuQuota": 150000, "CpuPeriod": 100000}), true, ""},
+		{"quota default period", create(obj{"CpuQuota": 250000}), false, "--cpus of 2.5 exceeds the maximum of 2"},
+		{"shares", create(obj{"CpuShares": 512}), true, ""},
+
+		{"update unchanged", updateReq(obj{"Memory": 1 << 30}), true, ""},
+		{"update nano cpus", updateReq(obj{"NanoCpus": 2000000000}), true, ""},
+		{"update nano cpus over max", updateReq(obj{"NanoCpus": 3000000000}), false, "--cpus of 3 exceeds the maximum of 2"},
+		{"update quota over max", updateReq(obj{"CpuQuota": 300000, "CpuPeriod": 100000}), false, "--cpus of 3 exceeds the maximum of 2"},
+		{"update removes limit", updateReq(obj{"CpuQuota": -1}), false, "removing the CPU limit is not allowed"},
+		{"update shares", updateReq(obj{"CpuShares": 2048}), true, ""},
+	})
+	runRuleCases(t, testPolicy(t, "cpu.enabled=true", "cpu.allow-shares=false"), []ruleCase{
+		{"shares not allowed", create(obj{"CpuShares": 512}), false, "a CPU limit is required: add --cpus"},
+	})
+}
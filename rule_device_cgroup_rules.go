@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// deviceCgroupRulesRule denies container creation when an entry in
+// HostConfig.DeviceCgroupRules grants more device access than one of the
+// allowed rules.
+type deviceCgroupRulesRule struct {
+	ruleOptions
+
+	// Allow is a list of device cgroup rules, in the same format as
+	// --device-cgroup-rule, that entries must fall within. A * major or minor
+	// number covers any number, and an "a" type covers both "b" and "c".
+	Allow []string `json:"allow"`
+
+	allowed []deviceCgroupRule
+}
+
+// deviceCgroupRule is a parsed device cgroup rule in the format
+// "type major:minor access". A major or minor of -1 is the * wildcard.
+type deviceCgroupRule struct {
+	Type   string
+	Major  int64
+	Minor  int64
+	Access string
+}
+
+// newDeviceCgroupRulesRule returns a deviceCgroupRulesRule with its default
+// settings.
+func newDeviceCgroupRulesRule() rule {
+	return &deviceCgroupRulesRule{}
+}
+
+// Name implements rule for deviceCgroupRulesRule.
+func (r *deviceCgroupRulesRule) Name() string {
+	return "device-cgroup-rules"
+}
+
+// Code implements rule for deviceCgroupRulesRule.
+func (r *deviceCgroupRulesRule) Code() string {
+	return "DUH-DEVICE-CGROUP-RULE"
+}
+
+// validate implements validator for deviceCgroupRulesRule.
+func (r *deviceCgroupRulesRule) validate() error {
+	r.allowed = nil
+	for _, s := range r.Allow {
+		dr, err := parseDeviceCgroupRule(s)
+		if err != nil {
+			return fmt.Errorf("allow: %v", err)
+		}
+		r.allowed = append(r.allowed, dr)
+	}
+	return nil
+}
+
+// Evaluate implements rule for deviceCgroupRulesRule.
+func (r *deviceCgroupRulesRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	for _, s := range toStrings(ctx.hostConfig()["DeviceCgroupRules"]) {
+		dr, err := parseDeviceCgroupRule(s)
+		if err != nil {
+			ctx.logger().Warnf("Denying malformed device cgroup rule: %v", err)
+			return deny("--device-cgroup-rule %q is malformed", s)
+		}
+		if !r.permits(dr) {
+			return deny("--device-cgroup-rule %q is not allowed", s)
+		}
+	}
+	return allow()
+}
+
+// permits returns true if dr falls within one of the allowed rules.
+func (r *deviceCgroupRulesRule) permits(dr deviceCgroupRule) bool {
+	for _, a := range r.allowed {
+		if a.covers(dr) {
+			return true
+		}
+	}
+	return false
+}
+
+// covers returns true if every device and access granted by dr is also
+// granted by r.
+func (r deviceCgroupRule) covers(dr deviceCgroupRule) bool {
+	if r.Type != "a" && r.Type != dr.Type {
+		return false
+	}
+	if r.Major != -1 && r.Major != dr.Major || r.Minor != -1 && r.Minor != dr.Minor {
+		return false
+	}
+	for _, c := range dr.Access {
+		if !strings.ContainsRune(r.Access, c) {
+			return false
+		}
+	}
+	return true
+}
+
+// parseDeviceCgroupRule parses a device cgroup rule, ie: "c 1:3 rwm". An "a"
+// type applies to all devices, and is the same as "a *:* rwm".
+func parseDeviceCgroupRule(s string) (deviceCgroupRule, error) {
+	f := strings.Fields(s)
+	if len(f) == 1 && f[0] == "a" {
+		return deviceCgroupRule{Type: "a", Major: -1, Minor: -1, Access: "rwm"}, nil
+	}
+	if len(f) != 3 {
+		return deviceCgroupRule{}, fmt.Errorf("%q: expected \"type major:minor access\"", s)
+	}
+	dr := deviceCgroupRule{Type: f[0], Access: f[2]}
+	switch dr.Type {
+	case "a", "b", "c":
+	default:
+		return deviceCgroupRule{}, fmt.Errorf("%q: invalid type %q", s, dr.Type)
+	}
+	i := strings.Index(f[1], ":")
+	if i < 0 {
+		return deviceCgroupRule{}, fmt.Errorf("%q: invalid device number %q", s, f[1])
+	}
+	var err error
+	if dr.Major, err = parseDeviceNumber(f[1][:i]); err != nil {
+		return deviceCgroupRule{}, fmt.Errorf("%q: invalid major number %q", s, f[1][:i])
+	}
+	if dr.Minor, err = parseDeviceNumber(f[1][i+1:]); err != nil {
+		return deviceCgroupRule{}, fmt.Errorf("%q: invalid minor number %q", s, f[1][i+1:])
+	}
+	if dr.Access == "" || strings.Trim(dr.Access, "rwm") != "" {
+		return deviceCgroupRule{}, fmt.Errorf("%q: invalid access %q", s, dr.Access)
+	}
+	return dr, nil
+}
+
+// parseDeviceNumber parses a device major or minor number, returning -1 for
+// the * wildcard.
+func parseDeviceNumber(s string) (int64, error) {
+	if s == "*" {
+		return -1, nil
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	return int64(n), err
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// dnsRule denies container creation when HostConfig.Dns, DnsSearch, or
+// DnsOptions override the host resolver configuration in a way that is not
+// allowed.
+type dnsRule struct {
+	ruleOptions
+	exemptions
+
+	// AllowServers is a list of CIDR networks that --dns servers must be in.
+	// An empty list denies any --dns servers.
+	AllowServers []string `json:"allow-servers"`
+
+	// DenySearch is a list of glob patterns for denied --dns-search domains.
+	DenySearch []string `json:"deny-search"`
+
+	// DenyOptions is a list of denied --dns-option names, ie: ndots. Options
+	// are matched by name, ignoring any :value suffix.
+	DenyOptions []string `json:"deny-options"`
+
+	allowServers []*net.IPNet
+}
+
+// newDNSRule returns a dnsRule with its default settings.
+func newDNSRule() rule {
+	return &dnsRule{}
+}
+
+// Name implements rule for dnsRule.
+func (r *dnsRule) Name() string {
+	return "dns"
+}
+
+// Code implements rule for dnsRule.
+func (r *dnsRule) Code() string {
+	return "DUH-DNS"
+}
+
+// validate implements validator for dnsRule.
+func (r *dnsRule) validate() error {
+	r.allowServers = nil
+	for _, s := range r.AllowServers {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("allow-servers: %v", err)
+		}
+		r.allowServers = append(r.allowServers, n)
+	}
+	return nil
+}
+
+// Evaluate implements rule for dnsRule.
+func (r *dnsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	var denied []string
+	for _, s := range toStrings(hc["Dns"]) {
+		if !r.serverAllowed(s) {
+			denied = append(denied, s)
+		}
+	}
+	if len(denied) > 0 {
+		return deny("--dns %s is not allowed", strings.Join(denied, ", "))
+	}
+	for _, s := range toStrings(hc["DnsSearch"]) {
+		if matchAny(r.DenySearch, s) {
+			return deny("--dns-search %s is not allowed", s)
+		}
+	}
+	for _, s := range toStrings(hc["DnsOptions"]) {
+		name := strings.SplitN(s, ":", 2)[0]
+		for _, o := range r.DenyOptions {
+			if name == o {
+				return deny("--dns-option %s is not allowed", s)
+			}
+		}
+	}
+	return allow()
+}
+
+// serverAllowed returns true if s is an IP address in one of the allowed
+// networks.
+func (r *dnsRule) serverAllowed(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	for _, n := range r.allowServers {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
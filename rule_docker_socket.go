@@ -0,0 +1,72 @@
+package main
+
+import "path/filepath"
+
+// dockerSocketRule denies container creation when the Docker socket, or a
+// directory holding it, is bind mounted into the container, either through
+// HostConfig.Binds or HostConfig.Mounts.
+//
+// This is separate from bindsRule so that it can be enabled on its own.
+type dockerSocketRule struct {
+	ruleOptions
+
+	// Sockets is the list of paths to the Docker socket. Symlinks are resolved
+	// on both these paths and the mount source before comparing them.
+	Sockets []string `json:"sockets"`
+
+	exemptions
+}
+
+// newDockerSocketRule returns a dockerSocketRule with its default settings.
+func newDockerSocketRule() rule {
+	return &dockerSocketRule{
+		ruleOptions: ruleOptions{Enabled: true},
+		Sockets:     []string{"/var/run/docker.sock", "/run/docker.sock"},
+	}
+}
+
+// Name implements rule for dockerSocketRule.
+func (r *dockerSocketRule) Name() string {
+	return "docker-socket"
+}
+
+// Code implements rule for dockerSocketRule.
+func (r *dockerSocketRule) Code() string {
+	return "DUH-DOCKER-SOCKET"
+}
+
+// Evaluate implements rule for dockerSocketRule.
+func (r *dockerSocketRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	var sockets []string
+	for _, v := range r.Sockets {
+		sockets = append(sockets, resolvePath(v)...)
+	}
+	for _, src := range ctx.hostSources() {
+		for _, p := range resolvePath(src) {
+			for _, socket := range sockets {
+				switch {
+				case p == socket:
+					return deny("mounting the Docker socket (%s) is not allowed", src)
+				case pathHasPrefix(socket, p) || p == "/":
+					// Mounting any parent of the socket exposes it too.
+					return deny("mounting %s is not allowed, as it exposes the Docker socket %s", src, socket)
+				}
+			}
+		}
+	}
+	return allow()
+}
+
+// resolvePath returns the cleaned path, along with the path with all symlinks
+// resolved if that differs and the path exists.
+func resolvePath(path string) []string {
+	path = filepath.Clean(path)
+	paths := []string{path}
+	if p, err := filepath.EvalSymlinks(path); err == nil && p != path {
+		paths = append(paths, p)
+	}
+	return paths
+}
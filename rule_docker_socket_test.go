@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestDockerSocketRule(t *testing.T) {
+	binds := func(b ...interface{}) authzReq {
+		return newAuthzReq("POST", "/v1.41/containers/create", createBody(obj{"Binds": arr(b)}))
+	}
+	mount := func(src string) authzReq {
+		return newAuthzReq("POST", "/v1.41/containers/create", createBody(obj{"Mounts": arr{obj{"Type": "bind", "Source": src, "Target": "/mnt"}}}))
+	}
+	exempt := createBody(obj{"Binds": arr{"/var/run/docker.sock:/var/run/docker.sock"}})
+	exempt["Image"] = "traefik:2"
+	runRuleCases(t, testPolicy(t, "binds.enabled=false", "docker-socket.exempt-images=traefik*"), []ruleCase{
+		{"no mounts", binds(), true, ""},
+		{"socket", binds("/var/run/docker.sock:/var/run/docker.sock"), false, "mounting the Docker socket (/var/run/docker.sock) is not allowed"},
+		{"socket read-only", binds("/run/docker.sock:/docker.sock:ro"), false, "mounting the Docker socket (/run/docker.sock) is not allowed"},
+		{"unclean socket path", binds("/var//run/./docker.sock:/docker.sock"), false, ""},
+		{"parent", binds("/run:/host/run"), false, "mounting /run is not allowed, as it exposes the Docker socket /run/docker.sock"},
+		{"grandparent", binds("/var:/host/var:ro"), false, "mounting /var is not allowed, as it exposes the Docker socket /var/run/docker.sock"},
+		{"root", binds("/:/host"), false, ""},
+		{"sibling", binds("/run/user:/run/user", "/var/lib/app:/data"), true, ""},
+		{"similar name", binds("/var/run/docker.sock.d:/x", "/runner:/runner"), true, ""},
+		{"named volume", binds("run:/run"), true, ""},
+		{"mount socket", mount("/var/run/docker.sock"), false, "mounting the Docker socket (/var/run/docker.sock) is not allowed"},
+		{"mount parent", mount("/var/run"), false, ""},
+		{"exempt image", newAuthzReq("POST", "/containers/create", exempt), true, ""},
+	})
+}
@@ -0,0 +1,34 @@
+package main
+
+// execPrivilegedRule denies docker exec --privileged, which gives the exec
+// process all capabilities in an existing container, even if the container
+// itself was not created privileged.
+type execPrivilegedRule struct {
+	ruleOptions
+}
+
+// newExecPrivilegedRule returns an execPrivilegedRule with its default
+// settings.
+func newExecPrivilegedRule() rule {
+	return &execPrivilegedRule{}
+}
+
+// Name implements rule for execPrivilegedRule.
+func (r *execPrivilegedRule) Name() string {
+	return "exec-privileged"
+}
+
+// Code implements rule for execPrivilegedRule.
+func (r *execPrivilegedRule) Code() string {
+	return "DUH-EXEC-PRIVILEGED"
+}
+
+// Evaluate implements rule for execPrivilegedRule.
+func (r *execPrivilegedRule) Evaluate(ctx *evalContext) decision {
+	id, ok := ctx.execContainer()
+	if !ok || !getBool(ctx.body, "Privileged") {
+		return allow()
+	}
+	ctx.logData["ContainerID"] = id
+	return deny("docker exec --privileged is not allowed")
+}
@@ -0,0 +1,45 @@
+package main
+
+// execRootRule denies docker exec as root. Exec processes run as the user in
+// the exec request's User field, which defaults to the container's user, so
+// an empty User is treated as root the same as for container creation.
+type execRootRule struct {
+	ruleOptions
+
+	// ExemptUsers is a list of authenticated users (the TLS client
+	// certificate's common name) that may exec as root, ie: break-glass
+	// accounts.
+	ExemptUsers []string `json:"exempt-users"`
+}
+
+// newExecRootRule returns an execRootRule with its default settings.
+func newExecRootRule() rule {
+	return &execRootRule{}
+}
+
+// Name implements rule for execRootRule.
+func (r *execRootRule) Name() string {
+	return "exec-root"
+}
+
+// Code implements rule for execRootRule.
+func (r *execRootRule) Code() string {
+	return "DUH-EXEC-ROOT"
+}
+
+// Evaluate implements rule for execRootRule.
+func (r *execRootRule) Evaluate(ctx *evalContext) decision {
+	id, ok := ctx.execContainer()
+	if !ok || ctx.req.authenticated() && inList(r.ExemptUsers, ctx.req.User) {
+		return allow()
+	}
+	user := getString(ctx.body, "User")
+	if !isRootUser(user) {
+		return allow()
+	}
+	ctx.logData["ContainerID"] = id
+	if user == "" {
+		return deny("docker exec as root is not allowed, set a non-root user with --user")
+	}
+	return deny("docker exec as root (--user %s) is not allowed", user)
+}
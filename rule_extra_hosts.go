@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// hostGateway is the special --add-host address that resolves to the host.
+const hostGateway = "host-gateway"
+
+// extraHostsRule denies container creation when HostConfig.ExtraHosts has
+// entries that could be used to reach the host or other sensitive addresses.
+type extraHostsRule struct {
+	ruleOptions
+	exemptions
+
+	// DenyAll denies any --add-host entries at all.
+	DenyAll bool `json:"deny-all"`
+
+	// DenyHostGateway denies entries using the special host-gateway address.
+	DenyHostGateway bool `json:"deny-host-gateway"`
+
+	// DenyLoopback denies entries pointing to a loopback address, or to the
+	// unspecified address (0.0.0.0 or ::), which also reaches the host.
+	DenyLoopback bool `json:"deny-loopback"`
+
+	// DenyHosts is a list of glob patterns for protected hostnames that may
+	// not be added, ie: metadata.google.internal or *.internal. Hostnames and
+	// patterns are compared case-insensitively, and without a trailing dot.
+	DenyHosts []string `json:"deny-hosts"`
+
+	// AllowHosts is a list of glob patterns for the hostnames that may be
+	// added, compared the same way as DenyHosts. An empty list allows any
+	// hostname.
+	AllowHosts []string `json:"allow-hosts"`
+
+	// DenyNetworks is a list of CIDR networks that added hosts may not
+	// point to.
+	DenyNetworks []string `json:"deny-networks"`
+
+	denyNetworks []*net.IPNet
+}
+
+// newExtraHostsRule returns an extraHostsRule with its default settings.
+func newExtraHostsRule() rule {
+	return &extraHostsRule{
+		DenyHostGateway: true,
+		DenyLoopback:    true,
+		DenyNetworks:    []string{"169.254.0.0/16", "fe80::/10"},
+	}
+}
+
+// Name implements rule for extraHostsRule.
+func (r *extraHostsRule) Name() string {
+	return "extra-hosts"
+}
+
+// Code implements rule for extraHostsRule.
+func (r *extraHostsRule) Code() string {
+	return "DUH-EXTRA-HOST"
+}
+
+// validate implements validator for extraHostsRule.
+func (r *extraHostsRule) validate() error {
+	for i, h := range r.DenyHosts {
+		r.DenyHosts[i] = normalizeHostname(h)
+	}
+	for i, h := range r.AllowHosts {
+		r.AllowHosts[i] = normalizeHostname(h)
+	}
+	r.denyNetworks = nil
+	for _, s := range r.DenyNetworks {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("deny-networks: %v", err)
+		}
+		r.denyNetworks = append(r.denyNetworks, n)
+	}
+	return nil
+}
+
+// Evaluate implements rule for extraHostsRule.
+func (r *extraHostsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	for _, s := range toStrings(ctx.hostConfig()["ExtraHosts"]) {
+		if r.DenyAll {
+			return deny("--add-host %s is not allowed", s)
+		}
+		host, addr, err := parseExtraHost(s)
+		if err != nil {
+			return deny("--add-host %s is malformed: %v", s, err)
+		}
+		if matchAny(r.DenyHosts, normalizeHostname(host)) {
+			return deny("--add-host %s: hostname %s is protected", s, host)
+		}
+		if len(r.AllowHosts) > 0 && !matchAny(r.AllowHosts, normalizeHostname(host)) {
+			return deny("--add-host %s: hostname %s is not allowed", s, host)
+		}
+		if addr == hostGateway {
+			if r.DenyHostGateway {
+				return deny("--add-host %s: %s is not allowed", s, hostGateway)
+			}
+			continue
+		}
+		ip := net.ParseIP(addr)
+		if r.DenyLoopback && (ip.IsLoopback() || ip.IsUnspecified()) {
+			return deny("--add-host %s: loopback address %s is not allowed", s, addr)
+		}
+		for _, n := range r.denyNetworks {
+			if n.Contains(ip) {
+				return deny("--add-host %s: address %s is in the denied network %s", s, addr, n)
+			}
+		}
+	}
+	return allow()
+}
+
+// normalizeHostname returns the hostname h in lower case and without the
+// trailing dot of a fully qualified name, as resolvers treat these the same.
+func normalizeHostname(h string) string {
+	return strings.TrimSuffix(strings.ToLower(h), ".")
+}
+
+// parseExtraHost splits a HostConfig.ExtraHosts entry, in the format
+// host:address, into its hostname and address. Hostnames can't contain
+// colons, so the entry is split on the first one, leaving any IPv6 address
+// intact. Brackets around an IPv6 address are removed. The address must be
+// an IP address or host-gateway.
+func parseExtraHost(s string) (string, string, error) {
+	i := strings.Index(s, ":")
+	if i <= 0 {
+		return "", "", fmt.Errorf("expected host:address")
+	}
+	host, addr := s[:i], s[i+1:]
+	if strings.HasPrefix(addr, "[") && strings.HasSuffix(addr, "]") {
+		addr = addr[1 : len(addr)-1]
+	}
+	if addr != hostGateway && net.ParseIP(addr) == nil {
+		return "", "", fmt.Errorf("invalid address %q", addr)
+	}
+	return host, addr, nil
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestExtraHostsRule(t *testing.T) {
+	hosts := func(h ...interface{}) authzReq {
+		return newAuthzReq("POST", "/containers/create", createBody(obj{"ExtraHosts": arr(h)}))
+	}
+	p := testPolicy(t, "extra-hosts.enabled=true", "extra-hosts.deny-hosts=Metadata.Google.Internal,*.corp.")
+	runRuleCases(t, p, []ruleCase{
+		{"none", hosts(), true, ""},
+		{"plain", hosts("db:10.0.0.5", "db6:2001:db8::1", "db6b:[2001:db8::2]"), true, ""},
+		{"host-gateway", hosts("host.docker.internal:host-gateway"), false, "--add-host host.docker.internal:host-gateway: host-gateway is not allowed"},
+		{"loopback", hosts("api:127.0.0.2"), false, "--add-host api:127.0.0.2: loopback address 127.0.0.2 is not allowed"},
+		{"unspecified v6", hosts("api:[::]"), false, ""},
+		{"link-local", hosts("meta:169.254.169.254"), false, "--add-host meta:169.254.169.254: address 169.254.169.254 is in the denied network 169.254.0.0/16"},
+		{"malformed", hosts("nocolon"), false, "--add-host nocolon is malformed: expected host:address"},
+		{"bad address", hosts("db:example.com"), false, ""},
+		{"protected", hosts("metadata.google.internal:10.0.0.1"), false, "--add-host metadata.google.internal:10.0.0.1: hostname metadata.google.internal is protected"},
+		{"protected mixed case", hosts("METADATA.google.Internal:10.0.0.1"), false, ""},
+		{"protected trailing dot", hosts("metadata.google.internal.:10.0.0.1"), false, ""},
+		{"protected glob", hosts("Git.Corp:10.0.0.1"), false, ""},
+	})
+	runRuleCases(t, testPolicy(t, "extra-hosts.enabled=true", "extra-hosts.allow-hosts=*.Example.COM"), []ruleCase{
+		{"allowed", hosts("db.example.com:10.0.0.5"), true, ""},
+		{"allowed mixed case", hosts("DB.Example.com.:10.0.0.5"), true, ""},
+		{"not allowed", hosts("db.example.org:10.0.0.5"), false, "--add-host db.example.org:10.0.0.5: hostname db.example.org is not allowed"},
+	})
+	runRuleCases(t, testPolicy(t, "extra-hosts.enabled=true", "extra-hosts.deny-all=true"), []ruleCase{
+		{"deny all", hosts("db:10.0.0.5"), false, "--add-host db:10.0.0.5 is not allowed"},
+	})
+}
@@ -0,0 +1,55 @@
+package main
+
+import "strings"
+
+// groupAddRule denies container creation when HostConfig.GroupAdd has
+// supplementary groups that grant root or docker socket access, even to a
+// non-root user.
+type groupAddRule struct {
+	ruleOptions
+	exemptions
+
+	// Deny is the list of denied groups, as names or numeric GIDs.
+	Deny []string `json:"deny"`
+}
+
+// newGroupAddRule returns a groupAddRule with its default settings.
+func newGroupAddRule() rule {
+	return &groupAddRule{
+		Deny: []string{"0", "root", "docker", "sudo", "wheel"},
+	}
+}
+
+// Name implements rule for groupAddRule.
+func (r *groupAddRule) Name() string {
+	return "group-add"
+}
+
+// Code implements rule for groupAddRule.
+func (r *groupAddRule) Code() string {
+	return "DUH-GROUP-ADD"
+}
+
+// Evaluate implements rule for groupAddRule.
+func (r *groupAddRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	var denied []string
+	for _, g := range toStrings(ctx.hostConfig()["GroupAdd"]) {
+		for _, d := range r.Deny {
+			if sameID(g, d) {
+				denied = append(denied, g)
+				break
+			}
+		}
+	}
+	switch len(denied) {
+	case 0:
+		return allow()
+	case 1:
+		return deny("--group-add %s is not allowed", denied[0])
+	default:
+		return deny("--group-add %s are not allowed", strings.Join(denied, ", "))
+	}
+}
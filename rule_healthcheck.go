@@ -0,0 +1,103 @@
+package main
+
+import (
+	"strings"
+	"time"
+)
+
+// defaultHealthcheckInterval is the interval the daemon uses for healthchecks
+// without one.
+const defaultHealthcheckInterval = 30 * time.Second
+
+// healthcheckRule denies container creation that disables the image's
+// healthcheck, or doesn't define one.
+type healthcheckRule struct {
+	ruleOptions
+	exemptions
+
+	// DenyDisabled denies disabling the healthcheck, with --no-healthcheck or
+	// a Test of ["NONE"].
+	DenyDisabled bool `json:"deny-disabled"`
+
+	// Require denies containers created without a healthcheck in the
+	// request. Healthchecks defined in the image can't be seen by the plugin,
+	// so these containers need to repeat them, ie: with --health-cmd.
+	Require bool `json:"require"`
+
+	// MinInterval is the shortest healthcheck interval allowed, ie: "10s".
+	// Zero allows any interval.
+	MinInterval duration `json:"min-interval"`
+}
+
+// healthcheck is the Healthcheck section of a container create request.
+type healthcheck struct {
+	// Test is the healthcheck command, empty to use the image's
+	// healthcheck.
+	Test []string
+
+	// Interval and Timeout are zero when not set, to use the image's
+	// settings or the daemon's defaults.
+	Interval time.Duration
+	Timeout  time.Duration
+}
+
+// newHealthcheckRule returns a healthcheckRule with its default settings.
+func newHealthcheckRule() rule {
+	return &healthcheckRule{
+		DenyDisabled: true,
+	}
+}
+
+// Name implements rule for healthcheckRule.
+func (r *healthcheckRule) Name() string {
+	return "healthcheck"
+}
+
+// Code implements rule for healthcheckRule.
+func (r *healthcheckRule) Code() string {
+	return "DUH-HEALTHCHECK"
+}
+
+// Evaluate implements rule for healthcheckRule.
+func (r *healthcheckRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	hc := ctx.healthcheck()
+	switch {
+	case r.DenyDisabled && hc.disabled():
+		return deny("disabling the healthcheck is not allowed")
+	case r.Require && len(hc.Test) == 0:
+		return deny("containers must define a healthcheck, use --health-cmd")
+	}
+	if min := time.Duration(r.MinInterval); min > 0 && !hc.disabled() {
+		interval := hc.Interval
+		if interval == 0 {
+			interval = defaultHealthcheckInterval
+		}
+		if interval < min {
+			return deny("healthcheck interval %s is not allowed, the minimum is %s", interval, min)
+		}
+	}
+	return allow()
+}
+
+// healthcheck returns the Healthcheck section of the request body. The zero
+// healthcheck is returned if there is none.
+func (c *evalContext) healthcheck() healthcheck {
+	m := getMap(c.body, "Healthcheck")
+	hc := healthcheck{Test: toStrings(m["Test"])}
+	if n, ok := toInt64(m["Interval"]); ok {
+		hc.Interval = time.Duration(n)
+	}
+	if n, ok := toInt64(m["Timeout"]); ok {
+		hc.Timeout = time.Duration(n)
+	}
+	return hc
+}
+
+// disabled returns true if the healthcheck turns off the image's
+// healthcheck.
+func (hc healthcheck) disabled() bool {
+	return len(hc.Test) == 1 && strings.ToUpper(hc.Test[0]) == "NONE"
+}
@@ -0,0 +1,50 @@
+package main
+
+// hostDevPath is the host's device directory.
+const hostDevPath = "/dev"
+
+// hostDevRule denies container creation when a bind mount's host source is
+// /dev or a path under it, which exposes host devices without going through
+// --device.
+type hostDevRule struct {
+	ruleOptions
+
+	// Allow is a list of glob patterns for the paths under /dev that may be
+	// bind mounted, ie: /dev/fuse. /dev itself is never allowed.
+	Allow []string `json:"allow"`
+}
+
+// newHostDevRule returns a hostDevRule with its default settings.
+func newHostDevRule() rule {
+	return &hostDevRule{
+		ruleOptions: ruleOptions{Enabled: true},
+	}
+}
+
+// Name implements rule for hostDevRule.
+func (r *hostDevRule) Name() string {
+	return "host-dev"
+}
+
+// Code implements rule for hostDevRule.
+func (r *hostDevRule) Code() string {
+	return "DUH-HOST-DEV"
+}
+
+// Evaluate implements rule for hostDevRule.
+func (r *hostDevRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	for _, src := range ctx.hostSources() {
+		for _, p := range resolvePath(src) {
+			switch {
+			case p == hostDevPath:
+				return deny("bind mount of the host's entire %s is not allowed", hostDevPath)
+			case pathHasPrefix(p, hostDevPath) && !matchAny(r.Allow, p):
+				return deny("bind mount of host device path %s under %s is not allowed", p, hostDevPath)
+			}
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// hostPortsRule denies container creation when HostConfig.PortBindings
+// publishes a container port on a privileged host port.
+type hostPortsRule struct {
+	ruleOptions
+	exemptions
+
+	// MinPort is the lowest host port that can be published without being in
+	// AllowPorts.
+	MinPort int `json:"min-port"`
+
+	// AllowPorts is a list of host ports below MinPort that are allowed.
+	AllowPorts []int `json:"allow-ports"`
+}
+
+// newHostPortsRule returns a hostPortsRule with its default settings.
+func newHostPortsRule() rule {
+	return &hostPortsRule{
+		MinPort: 1024,
+	}
+}
+
+// Name implements rule for hostPortsRule.
+func (r *hostPortsRule) Name() string {
+	return "host-ports"
+}
+
+// Code implements rule for hostPortsRule.
+func (r *hostPortsRule) Code() string {
+	return "DUH-HOST-PORT"
+}
+
+// Evaluate implements rule for hostPortsRule.
+func (r *hostPortsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	bindings := getMap(ctx.hostConfig(), "PortBindings")
+	var ports []string
+	for k := range bindings {
+		ports = append(ports, k)
+	}
+	sort.Strings(ports)
+	for _, containerPort := range ports {
+		l := getSlice(bindings, containerPort)
+		for _, v := range l {
+			b, _ := v.(map[string]interface{})
+			hostPort := getString(b, "HostPort")
+			// An empty host port, or 0, has the daemon pick an ephemeral port.
+			if hostPort == "" || hostPort == "0" {
+				continue
+			}
+			low, high, err := parsePortRange(hostPort)
+			if err != nil {
+				return deny("invalid host port %q for container port %s: %v", hostPort, containerPort, err)
+			}
+			for p := low; p <= high; p++ {
+				if p > 0 && p < r.MinPort && !r.allowed(p) {
+					return deny("publishing container port %s on privileged host port %d is not allowed", containerPort, p)
+				}
+			}
+		}
+	}
+	return allow()
+}
+
+// allowed returns true if port is in AllowPorts.
+func (r *hostPortsRule) allowed(port int) bool {
+	for _, p := range r.AllowPorts {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+// parsePortRange parses a port, or a range of ports in the form low-high.
+func parsePortRange(s string) (low, high int, err error) {
+	parts := strings.SplitN(s, "-", 2)
+	if low, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	high = low
+	if len(parts) == 2 {
+		if high, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, err
+		}
+	}
+	if low < 0 || high > 65535 || low > high {
+		return 0, 0, fmt.Errorf("port range out of bounds")
+	}
+	return low, high, nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestHostPortsRule(t *testing.T) {
+	ports := func(bindings obj) authzReq {
+		return newAuthzReq("POST", "/v1.41/containers/create", createBody(obj{"PortBindings": bindings}))
+	}
+	bind := func(hostPort string) arr {
+		return arr{obj{"HostIp": "", "HostPort": hostPort}}
+	}
+	asUser := func(req authzReq, user string) authzReq {
+		req.User, req.UserAuthNMethod = user, "TLS"
+		return req
+	}
+	p := testPolicy(t, "host-ports.enabled=true", "host-ports.allow-ports=[443]", "host-ports.exempt-users=deploy-bot")
+	runRuleCases(t, p, []ruleCase{
+		{"none", ports(nil), true, ""},
+		{"high port", ports(obj{"8080/tcp": bind("8080")}), true, ""},
+		{"privileged port", ports(obj{"8080/tcp": bind("80")}), false, "publishing container port 8080/tcp on privileged host port 80 is not allowed"},
+		{"allowed port", ports(obj{"8443/tcp": bind("443")}), true, ""},
+		{"ephemeral", ports(obj{"80/tcp": bind("")}), true, ""},
+		{"ephemeral zero", ports(obj{"80/tcp": bind("0")}), true, ""},
+		{"range", ports(obj{"70-90/tcp": bind("70-90")}), false, "publishing container port 70-90/tcp on privileged host port 70 is not allowed"},
+		{"high range", ports(obj{"8000-8010/tcp": bind("8000-8010")}), true, ""},
+		{"range from zero", ports(obj{"80/tcp": bind("0-10")}), false, "publishing container port 80/tcp on privileged host port 1 is not allowed"},
+		{"invalid", ports(obj{"80/tcp": bind("http")}), false, ""},
+		{"exempt user", asUser(ports(obj{"8080/tcp": bind("80")}), "deploy-bot"), true, ""},
+		{"other user", asUser(ports(obj{"8080/tcp": bind("80")}), "alice"), false, ""},
+	})
+}
@@ -0,0 +1,67 @@
+package main
+
+import "strings"
+
+// imagePinningRule denies container creation from images that are not pinned
+// to a specific version, by forbidding the latest tag or requiring a digest.
+type imagePinningRule struct {
+	ruleOptions
+	exemptions
+
+	// ForbidLatest denies images with the latest tag, including references
+	// without a tag, which get latest implicitly.
+	ForbidLatest bool `json:"forbid-latest"`
+
+	// RequireDigest denies images that are not referenced by digest.
+	RequireDigest bool `json:"require-digest"`
+}
+
+// newImagePinningRule returns an imagePinningRule with its default settings.
+func newImagePinningRule() rule {
+	return &imagePinningRule{
+		ForbidLatest: true,
+	}
+}
+
+// Name implements rule for imagePinningRule.
+func (r *imagePinningRule) Name() string {
+	return "image-pinning"
+}
+
+// Code implements rule for imagePinningRule.
+func (r *imagePinningRule) Code() string {
+	return "DUH-IMAGE-PINNING"
+}
+
+// Evaluate implements rule for imagePinningRule.
+func (r *imagePinningRule) Evaluate(ctx *evalContext) decision {
+	image := ctx.image()
+	if !ctx.isContainerCreate() || r.exempt(ctx) || isImageID(image) {
+		return allow()
+	}
+	name, tag, digest := splitImageRef(image)
+	example := name + "@sha256:<digest>"
+	if r.RequireDigest && digest == "" {
+		return deny("image %s must be pinned by digest, ie: %s", image, example)
+	}
+	// A digest pins the image whatever the tag says.
+	if r.ForbidLatest && digest == "" && (tag == "" || tag == "latest") {
+		return deny("image %s uses the latest tag, pin a version or digest instead, ie: %s:<version> or %s", image, name, example)
+	}
+	return allow()
+}
+
+// splitImageRef splits an image reference into its name, tag, and digest,
+// ie: registry.local:5000/app:1.0@sha256:abc has the name
+// registry.local:5000/app, the tag 1.0, and the digest sha256:abc. The tag
+// and digest are empty when not given.
+func splitImageRef(ref string) (name, tag, digest string) {
+	if i := strings.Index(ref, "@"); i >= 0 {
+		ref, digest = ref[:i], ref[i+1:]
+	}
+	name = imageName(ref)
+	if name != ref {
+		tag = ref[len(name)+1:]
+	}
+	return name, tag, digest
+}
@@ -0,0 +1,98 @@
+package main
+
+import "strings"
+
+// defaultRegistry is the registry of image references that don't name one.
+const defaultRegistry = "docker.io"
+
+// imageRegistryRule denies container creation from images hosted on
+// registries that are not allowed.
+type imageRegistryRule struct {
+	ruleOptions
+
+	// Allow is a list of glob patterns for allowed registries, ie:
+	// registry.example.com:5000. An empty list allows any registry not in
+	// Deny. Images without a registry are on docker.io.
+	Allow []string `json:"allow"`
+
+	// Deny is a list of glob patterns for denied registries.
+	Deny []string `json:"deny"`
+
+	// AllowIDs allows creating containers from image IDs (sha256:...), which
+	// refer to already-pulled images and so have no registry.
+	AllowIDs bool `json:"allow-ids"`
+}
+
+// newImageRegistryRule returns an imageRegistryRule with its default settings.
+func newImageRegistryRule() rule {
+	return &imageRegistryRule{}
+}
+
+// Name implements rule for imageRegistryRule.
+func (r *imageRegistryRule) Name() string {
+	return "image-registry"
+}
+
+// Code implements rule for imageRegistryRule.
+func (r *imageRegistryRule) Code() string {
+	return "DUH-IMAGE-REGISTRY"
+}
+
+// Evaluate implements rule for imageRegistryRule.
+func (r *imageRegistryRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	image := ctx.image()
+	if isImageID(image) {
+		if !r.AllowIDs {
+			return deny("creating containers from image ID %s is not allowed, use an image reference from an allowed registry", image)
+		}
+		return allow()
+	}
+	registry, ref := normalizeImage(image)
+	if matchAny(r.Deny, registry) {
+		return deny("image %s is from registry %s, which is not allowed", ref, registry)
+	}
+	if len(r.Allow) > 0 && !matchAny(r.Allow, registry) {
+		return deny("image %s is from registry %s, which is not allowed, allowed registries are: %s", ref, registry, strings.Join(r.Allow, ", "))
+	}
+	return allow()
+}
+
+// isImageID returns true if ref is an image ID rather than a reference, ie:
+// sha256:<hex>, or a full 64 character hex ID.
+func isImageID(ref string) bool {
+	hex := strings.TrimPrefix(ref, "sha256:")
+	if hex != ref {
+		return hex != "" && isHex(hex)
+	}
+	return len(ref) == 64 && isHex(ref)
+}
+
+// isHex returns true if s only has lower case hex digits.
+func isHex(s string) bool {
+	return strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// normalizeImage returns the registry of the image reference ref, along with
+// the fully qualified reference, the same way the docker CLI does: the first
+// component is a registry only if it has a dot or port, or is localhost, and
+// images on docker.io without a namespace are in library. A reference without
+// a tag or digest gets the latest tag. ie: "ubuntu" becomes
+// "docker.io/library/ubuntu:latest".
+func normalizeImage(ref string) (registry, normalized string) {
+	registry, remainder := defaultRegistry, ref
+	if i := strings.Index(ref, "/"); i >= 0 {
+		if first := ref[:i]; strings.ContainsAny(first, ".:") || first == "localhost" {
+			registry, remainder = first, ref[i+1:]
+		}
+	}
+	if registry == defaultRegistry && !strings.Contains(remainder, "/") {
+		remainder = "library/" + remainder
+	}
+	if imageName(remainder) == remainder {
+		remainder += ":latest"
+	}
+	return registry, registry + "/" + remainder
+}
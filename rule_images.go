@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// imagesRule allows or denies container creation by image, using an ordered
+// list of image patterns where the last matching pattern wins, so that more
+// specific patterns listed later override general ones.
+type imagesRule struct {
+	ruleOptions
+
+	// Default is the action for images that match no pattern, allow (the
+	// default) or deny.
+	Default string `json:"default"`
+
+	// Match is the ordered list of image patterns.
+	Match []imageMatch `json:"match"`
+}
+
+// imageMatch is an entry in imagesRule.Match. Exactly one of Allow or Deny
+// must be set.
+//
+// Patterns are matched against both the image reference as given and its
+// fully qualified form, ie: docker.io/library/ubuntu:latest for ubuntu. * matches
+// any run of characters, including slashes, and ? matches any single
+// character. A pattern starting with ! matches images that don't match the
+// rest of the pattern.
+type imageMatch struct {
+	Allow string `json:"allow"`
+	Deny  string `json:"deny"`
+
+	re     *regexp.Regexp
+	negate bool
+}
+
+// newImagesRule returns an imagesRule with its default settings.
+func newImagesRule() rule {
+	return &imagesRule{}
+}
+
+// Name implements rule for imagesRule.
+func (r *imagesRule) Name() string {
+	return "images"
+}
+
+// Code implements rule for imagesRule.
+func (r *imagesRule) Code() string {
+	return "DUH-IMAGE"
+}
+
+// validate implements validator for imagesRule.
+func (r *imagesRule) validate() error {
+	switch r.Default {
+	case "":
+		r.Default = actionAllow
+	case actionAllow, actionDeny:
+	default:
+		return fmt.Errorf("default: invalid action %q, expected allow or deny", r.Default)
+	}
+	for i := range r.Match {
+		m := &r.Match[i]
+		if (m.Allow == "") == (m.Deny == "") {
+			return fmt.Errorf("match %d: exactly one of allow or deny must be set", i)
+		}
+		p := m.pattern()
+		if strings.HasPrefix(p, "!") {
+			m.negate, p = true, p[1:]
+		}
+		var expr []string
+		for _, part := range strings.Split(p, "*") {
+			expr = append(expr, strings.Replace(regexp.QuoteMeta(part), `\?`, ".", -1))
+		}
+		m.re = regexp.MustCompile("^" + strings.Join(expr, ".*") + "$")
+	}
+	return nil
+}
+
+// Evaluate implements rule for imagesRule.
+func (r *imagesRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	image := ctx.image()
+	_, normalized := normalizeImage(image)
+	var match *imageMatch
+	for i, m := range r.Match {
+		if m.matches(image, normalized) {
+			match = &r.Match[i]
+		}
+	}
+	if match == nil {
+		ctx.logData["ImageMatch"] = "default " + r.Default
+		if r.Default == actionDeny {
+			return deny("image %s is not allowed, as it matches no allowed image pattern", image)
+		}
+		return allow()
+	}
+	ctx.logData["ImageMatch"] = match.action() + " " + match.pattern()
+	if match.action() == actionDeny {
+		return deny("image %s is not allowed, as it matches denied image pattern %s", image, match.pattern())
+	}
+	return allow()
+}
+
+// pattern returns the pattern of the entry.
+func (m imageMatch) pattern() string {
+	if m.Allow != "" {
+		return m.Allow
+	}
+	return m.Deny
+}
+
+// action returns the action of the entry, allow or deny.
+func (m imageMatch) action() string {
+	if m.Allow != "" {
+		return actionAllow
+	}
+	return actionDeny
+}
+
+// matches returns true if any of refs, the forms of a single image
+// reference, match the entry's pattern.
+func (m imageMatch) matches(refs ...string) bool {
+	for _, ref := range refs {
+		if m.re.MatchString(ref) {
+			return !m.negate
+		}
+	}
+	return m.negate
+}
@@ -0,0 +1,46 @@
+package main
+
+// initRule denies container creation unless HostConfig.Init is true, so that
+// an init process reaps zombies for entrypoints that don't.
+type initRule struct {
+	ruleOptions
+	exemptions
+
+	// AllowDefault allows containers that leave Init unset (null), which use
+	// the daemon's default. Turn this on when the daemon runs with
+	// --init / "init": true.
+	AllowDefault bool `json:"allow-default"`
+}
+
+// newInitRule returns an initRule with its default settings.
+func newInitRule() rule {
+	return &initRule{}
+}
+
+// Name implements rule for initRule.
+func (r *initRule) Name() string {
+	return "init"
+}
+
+// Code implements rule for initRule.
+func (r *initRule) Code() string {
+	return "DUH-INIT"
+}
+
+// Evaluate implements rule for initRule.
+func (r *initRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	switch v := ctx.hostConfig()["Init"].(type) {
+	case bool:
+		if v {
+			return allow()
+		}
+	case nil:
+		if r.AllowDefault {
+			return allow()
+		}
+	}
+	return deny("an init process is required: add --init")
+}
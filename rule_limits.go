@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// limitsRule denies container creation with pathologically large container
+// configurations, such as thousands of binds or environment variables.
+//
+// Counts are taken from the decoded request body, so duplicate keys in
+// ExposedPorts and Labels are only counted once, the same as the daemon.
+type limitsRule struct {
+	ruleOptions
+
+	// The maximum number of entries in HostConfig.Binds, HostConfig.Mounts,
+	// Env, ExposedPorts, and Labels. Zero disables a limit.
+	MaxBinds        int `json:"max-binds"`
+	MaxMounts       int `json:"max-mounts"`
+	MaxEnv          int `json:"max-env"`
+	MaxExposedPorts int `json:"max-exposed-ports"`
+	MaxLabels       int `json:"max-labels"`
+
+	// MaxEnvSize is the maximum total size of the Env entries. Zero disables
+	// the limit.
+	MaxEnvSize byteSize `json:"max-env-size"`
+}
+
+// newLimitsRule returns a limitsRule with its default settings.
+func newLimitsRule() rule {
+	return &limitsRule{
+		MaxBinds:        100,
+		MaxMounts:       100,
+		MaxEnv:          1000,
+		MaxExposedPorts: 1000,
+		MaxLabels:       1000,
+		MaxEnvSize:      1 << 20,
+	}
+}
+
+// Name implements rule for limitsRule.
+func (r *limitsRule) Name() string {
+	return "limits"
+}
+
+// Code implements rule for limitsRule.
+func (r *limitsRule) Code() string {
+	return "DUH-LIMITS"
+}
+
+// Evaluate implements rule for limitsRule.
+func (r *limitsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	binds := getSlice(hc, "Binds")
+	mounts := getSlice(hc, "Mounts")
+	env := getSlice(ctx.body, "Env")
+	ports := getMap(ctx.body, "ExposedPorts")
+	labels := getMap(ctx.body, "Labels")
+	var exceeded []string
+	for _, l := range []struct {
+		name  string
+		count int
+		max   int
+	}{
+		{"binds", len(binds), r.MaxBinds},
+		{"mounts", len(mounts), r.MaxMounts},
+		{"environment variables", len(env), r.MaxEnv},
+		{"exposed ports", len(ports), r.MaxExposedPorts},
+		{"labels", len(labels), r.MaxLabels},
+	} {
+		if l.max > 0 && l.count > l.max {
+			exceeded = append(exceeded, fmt.Sprintf("%d %s, %d over the limit of %d", l.count, l.name, l.count-l.max, l.max))
+		}
+	}
+	var size byteSize
+	for _, e := range toStrings(ctx.body["Env"]) {
+		size += byteSize(len(e))
+	}
+	if r.MaxEnvSize > 0 && size > r.MaxEnvSize {
+		exceeded = append(exceeded, fmt.Sprintf("%d bytes of environment variables, %d over the limit of %s", size, size-r.MaxEnvSize, r.MaxEnvSize))
+	}
+	if len(exceeded) > 0 {
+		return deny("container configuration is too large: %s", strings.Join(exceeded, "; "))
+	}
+	return allow()
+}
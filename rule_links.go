@@ -0,0 +1,49 @@
+package main
+
+import "strings"
+
+// linksRule denies container creation when HostConfig.Links is set, as legacy
+// links share environment variables and /etc/hosts entries between
+// containers.
+type linksRule struct {
+	ruleOptions
+
+	// Allow is a list of glob patterns for the names of containers that may
+	// still be linked to.
+	Allow []string `json:"allow"`
+}
+
+// newLinksRule returns a linksRule with its default settings.
+func newLinksRule() rule {
+	return &linksRule{}
+}
+
+// Name implements rule for linksRule.
+func (r *linksRule) Name() string {
+	return "links"
+}
+
+// Code implements rule for linksRule.
+func (r *linksRule) Code() string {
+	return "DUH-LINK"
+}
+
+// Evaluate implements rule for linksRule.
+func (r *linksRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	for _, l := range toStrings(ctx.hostConfig()["Links"]) {
+		if name := linkTarget(l); !matchAny(r.Allow, name) {
+			return deny("--link to container %s is not allowed", name)
+		}
+	}
+	return allow()
+}
+
+// linkTarget returns the linked container name of a HostConfig.Links entry,
+// in the format name:alias. The daemon may send either part with a leading
+// slash, ie: /db:/web/db.
+func linkTarget(l string) string {
+	return strings.TrimPrefix(strings.SplitN(l, ":", 2)[0], "/")
+}
@@ -0,0 +1,62 @@
+package main
+
+import "strings"
+
+// logDriverRule denies container creation when HostConfig.LogConfig uses a
+// log driver that is not allowed, or is missing required log options.
+type logDriverRule struct {
+	ruleOptions
+	exemptions
+
+	// Allow is a list of allowed log drivers. An empty list allows any
+	// driver.
+	Allow []string `json:"allow"`
+
+	// RequireOptions is a list of --log-opt keys, ie: max-size, that must be
+	// set when a log driver is given.
+	RequireOptions []string `json:"require-options"`
+
+	// DenyDefault denies containers that do not set a log driver, and so use
+	// the daemon's default.
+	DenyDefault bool `json:"deny-default"`
+}
+
+// newLogDriverRule returns a logDriverRule with its default settings.
+func newLogDriverRule() rule {
+	return &logDriverRule{}
+}
+
+// Name implements rule for logDriverRule.
+func (r *logDriverRule) Name() string {
+	return "log-driver"
+}
+
+// Code implements rule for logDriverRule.
+func (r *logDriverRule) Code() string {
+	return "DUH-LOG-DRIVER"
+}
+
+// Evaluate implements rule for logDriverRule.
+func (r *logDriverRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	lc := getMap(ctx.hostConfig(), "LogConfig")
+	driver := getString(lc, "Type")
+	if driver == "" {
+		if r.DenyDefault {
+			return deny("a log driver is required: add --log-driver")
+		}
+		return allow()
+	}
+	if len(r.Allow) > 0 && !inList(r.Allow, driver) {
+		return deny("--log-driver=%s is not allowed, allowed drivers are: %s", driver, strings.Join(r.Allow, ", "))
+	}
+	opts := getMap(lc, "Config")
+	for _, k := range r.RequireOptions {
+		if _, ok := opts[k]; !ok {
+			return deny("--log-opt %s is required with --log-driver=%s", k, driver)
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net"
+	"sort"
+	"strings"
+)
+
+// macAddressRule denies container creation with an explicit MAC address,
+// either in the legacy top-level MacAddress field, or for a network in
+// NetworkingConfig.EndpointsConfig.
+type macAddressRule struct {
+	ruleOptions
+	exemptions
+
+	// AllowPrefixes is a list of allowed OUI prefixes, ie: 02:42:ac. Any MAC
+	// address starting with one of them is allowed.
+	AllowPrefixes []string `json:"allow-prefixes"`
+}
+
+// newMACAddressRule returns a macAddressRule with its default settings.
+func newMACAddressRule() rule {
+	return &macAddressRule{}
+}
+
+// Name implements rule for macAddressRule.
+func (r *macAddressRule) Name() string {
+	return "mac-address"
+}
+
+// Code implements rule for macAddressRule.
+func (r *macAddressRule) Code() string {
+	return "DUH-MAC-ADDRESS"
+}
+
+// Evaluate implements rule for macAddressRule.
+func (r *macAddressRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	addrs := make(map[string]string)
+	if mac := getString(ctx.body, "MacAddress"); mac != "" {
+		addrs["--mac-address"] = mac
+	}
+	endpoints := getMap(getMap(ctx.body, "NetworkingConfig"), "EndpointsConfig")
+	for name, v := range endpoints {
+		ep, _ := v.(map[string]interface{})
+		if mac := getString(ep, "MacAddress"); mac != "" {
+			addrs["network "+name] = mac
+		}
+	}
+	var denied []string
+	for where, mac := range addrs {
+		if !r.allowed(mac) {
+			denied = append(denied, mac+" ("+where+")")
+		}
+	}
+	if len(denied) == 0 {
+		return allow()
+	}
+	sort.Strings(denied)
+	return deny("setting a MAC address is not allowed: %s", strings.Join(denied, ", "))
+}
+
+// allowed returns true if mac starts with one of the allowed prefixes. MAC
+// addresses are compared in their canonical form, so that ie: 02-42-AC-11-00-02
+// matches the prefix 02:42:ac.
+func (r *macAddressRule) allowed(mac string) bool {
+	hw, err := net.ParseMAC(mac)
+	if err != nil {
+		return false
+	}
+	for _, p := range r.AllowPrefixes {
+		if strings.HasPrefix(hw.String(), strings.ToLower(strings.Replace(p, "-", ":", -1))) {
+			return true
+		}
+	}
+	return false
+}
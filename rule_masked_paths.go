@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maskedPathsRule denies container creation when HostConfig.MaskedPaths or
+// HostConfig.ReadonlyPaths are given, but leave out paths that the daemon
+// masks or makes read-only by default. Sending either as an empty list
+// unmasks everything, such as /proc/kcore, without --privileged.
+type maskedPathsRule struct {
+	ruleOptions
+	exemptions
+
+	// Masked is the list of paths that must be in MaskedPaths when it is
+	// given.
+	Masked []string `json:"masked"`
+
+	// Readonly is the list of paths that must be in ReadonlyPaths when it is
+	// given.
+	Readonly []string `json:"readonly"`
+}
+
+// newMaskedPathsRule returns a maskedPathsRule with its default settings,
+// which are the daemon's default masked and read-only paths.
+func newMaskedPathsRule() rule {
+	return &maskedPathsRule{
+		Masked: []string{
+			"/proc/asound",
+			"/proc/acpi",
+			"/proc/kcore",
+			"/proc/keys",
+			"/proc/latency_stats",
+			"/proc/timer_list",
+			"/proc/timer_stats",
+			"/proc/sched_debug",
+			"/proc/scsi",
+			"/sys/firmware",
+		},
+		Readonly: []string{
+			"/proc/bus",
+			"/proc/fs",
+			"/proc/irq",
+			"/proc/sys",
+			"/proc/sysrq-trigger",
+		},
+	}
+}
+
+// Name implements rule for maskedPathsRule.
+func (r *maskedPathsRule) Name() string {
+	return "masked-paths"
+}
+
+// Code implements rule for maskedPathsRule.
+func (r *maskedPathsRule) Code() string {
+	return "DUH-MASKED-PATHS"
+}
+
+// Evaluate implements rule for maskedPathsRule.
+func (r *maskedPathsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	var stripped []string
+	for _, f := range []struct {
+		key      string
+		baseline []string
+	}{
+		{"MaskedPaths", r.Masked},
+		{"ReadonlyPaths", r.Readonly},
+	} {
+		// A field that is absent or null gets the daemon's defaults. Only a
+		// list that is actually sent replaces them, and an empty list strips
+		// every default.
+		v, ok := hc[f.key].([]interface{})
+		if !ok {
+			continue
+		}
+		paths := toStrings(v)
+		var missing []string
+		for _, p := range f.baseline {
+			if !inList(paths, p) {
+				missing = append(missing, p)
+			}
+		}
+		if len(missing) > 0 {
+			stripped = append(stripped, fmt.Sprintf("%s is missing %s", f.key, strings.Join(missing, ", ")))
+		}
+	}
+	if len(stripped) > 0 {
+		return deny("removing the default masked and read-only paths is not allowed: %s", strings.Join(stripped, "; "))
+	}
+	return allow()
+}
@@ -0,0 +1,85 @@
+package main
+
+// memoryRule denies container creation unless HostConfig.Memory sets a memory
+// limit, optionally no larger than a maximum. It can also cap the combined
+// memory and swap limit in HostConfig.MemorySwap.
+type memoryRule struct {
+	ruleOptions
+	exemptions
+
+	// Max is the largest allowed memory limit. Zero allows any limit.
+	Max byteSize `json:"max"`
+
+	// MaxSwap is the largest allowed combined memory and swap limit. Zero
+	// disables the check.
+	MaxSwap byteSize `json:"max-swap"`
+}
+
+// newMemoryRule returns a memoryRule with its default settings.
+func newMemoryRule() rule {
+	return &memoryRule{}
+}
+
+// Name implements rule for memoryRule.
+func (r *memoryRule) Name() string {
+	return "memory"
+}
+
+// Code implements rule for memoryRule.
+func (r *memoryRule) Code() string {
+	return "DUH-MEMORY"
+}
+
+// Evaluate implements rule for memoryRule.
+func (r *memoryRule) Evaluate(ctx *evalContext) decision {
+	if id, ok := ctx.updateContainer(); ok {
+		return r.evaluateUpdate(ctx, id)
+	}
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	memory, _ := toInt64(hc["Memory"])
+	switch {
+	case memory <= 0 && r.Max > 0:
+		return deny("a memory limit is required: add --memory with a value up to %s", r.Max)
+	case memory <= 0:
+		return deny("a memory limit is required: add --memory")
+	case r.Max > 0 && byteSize(memory) > r.Max:
+		return deny("--memory of %s exceeds the maximum of %s", byteSize(memory), r.Max)
+	}
+	if r.MaxSwap <= 0 {
+		return allow()
+	}
+	// -1 is unlimited swap, and unset defaults to twice the memory limit.
+	swap, _ := toInt64(hc["MemorySwap"])
+	switch {
+	case swap < 0:
+		return deny("unlimited swap is not allowed: add --memory-swap with a value up to %s", r.MaxSwap)
+	case swap == 0:
+		swap = memory * 2
+	}
+	if byteSize(swap) > r.MaxSwap {
+		return deny("--memory-swap of %s exceeds the maximum of %s", byteSize(swap), r.MaxSwap)
+	}
+	return allow()
+}
+
+// evaluateUpdate checks a docker update of container id. Fields that are zero
+// leave the current limits unchanged, and -1 removes them.
+func (r *memoryRule) evaluateUpdate(ctx *evalContext, id string) decision {
+	ctx.logData["ContainerID"] = id
+	memory, _ := toInt64(ctx.body["Memory"])
+	swap, _ := toInt64(ctx.body["MemorySwap"])
+	switch {
+	case memory < 0:
+		return deny("removing the memory limit is not allowed")
+	case r.Max > 0 && byteSize(memory) > r.Max:
+		return deny("--memory of %s exceeds the maximum of %s", byteSize(memory), r.Max)
+	case r.MaxSwap > 0 && swap < 0:
+		return deny("unlimited swap is not allowed: add --memory-swap with a value up to %s", r.MaxSwap)
+	case r.MaxSwap > 0 && byteSize(swap) > r.MaxSwap:
+		return deny("--memory-swap of %s exceeds the maximum of %s", byteSize(swap), r.MaxSwap)
+	}
+	return allow()
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestMemoryRule(t *testing.T) {
+	create := func(hc obj) authzReq {
+		return newAuthzReq("POST", "/containers/create", createBody(hc))
+	}
+	p := testPolicy(t, "memory.enabled=true", "memory.max=1g", "memory.max-swap=2g")
+	runRuleCases(t, p, []ruleCase{
+		{"no limit", create(nil), false, "a memory limit is required: add --memory with a value up to 1g"},
+		{"within max", create(obj{"Memory": 512 << 20}), true, ""},
+		{"over max", create(obj{"Memory": 2 << 30}), false, "--memory of 2g exceeds the maximum of 1g"},
+		{"default swap", create(obj{"Memory": 1 << 30}), true, ""},
+		{"default swap over max", create(obj{"Memory": 1 << 30, "MemorySwap": 3 << 30}), false, "--memory-swap of 3g exceeds the maximum of 2g"},
+		{"unlimited swap", create(obj{"Memory": 1 << 30, "MemorySwap": -1}), false, "unlimited swap is not allowed: add --memory-swap with a value up to 2g"},
+
+		{"update unchanged", updateReq(obj{"CpuShares": 512}), true, ""},
+		{"update within max", updateReq(obj{"Memory": 256 << 20, "MemorySwap": 512 << 20}), true, ""},
+		{"update over max", updateReq(obj{"Memory": 4 << 30}), false, "--memory of 4g exceeds the maximum of 1g"},
+		{"update removes limit", updateReq(obj{"Memory": -1}), false, "removing the memory limit is not allowed"},
+		{"update unlimited swap", updateReq(obj{"MemorySwap": -1}), false, ""},
+		{"update swap over max", updateReq(obj{"MemorySwap": 3 << 30}), false, "--memory-swap of 3g exceeds the maximum of 2g"},
+		{"update bare body", newAuthzReq("POST", "/containers/abc/update", obj{"Memory": 4 << 30}), false, ""},
+		{"update nested HostConfig is not read", newAuthzReq("POST", "/containers/abc/update", obj{"HostConfig": obj{"Memory": -1}}), true, ""},
+		{"update with GET", newAuthzReq("GET", "/containers/abc/update", nil), true, ""},
+	})
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"sort"
+	"strings"
+)
+
+// networkCreateRule denies network creation with risky drivers and driver
+// options, such as macvlan networks on a host interface.
+type networkCreateRule struct {
+	ruleOptions
+
+	// AllowDrivers is a list of glob patterns for the network drivers that
+	// may be used. An empty list allows any driver not denied by Deny.
+	AllowDrivers []string `json:"allow-drivers"`
+
+	// Deny is a list of driver and option combinations that are denied.
+	Deny []networkDeny `json:"deny"`
+
+	// DenySubnets is a list of CIDR networks that IPAM subnets may not
+	// overlap, ie: the host's own network.
+	DenySubnets []string `json:"deny-subnets"`
+
+	denySubnets []*net.IPNet
+}
+
+// networkDeny is a denied combination of network driver and driver options.
+type networkDeny struct {
+	// Driver is a glob pattern for the network driver. An empty driver
+	// matches any driver.
+	Driver string `json:"driver"`
+
+	// Options maps driver options to glob patterns for their values. All of
+	// the options need to be set to a matching value for the combination to
+	// match, and * matches any value. No options match any network using the
+	// driver.
+	Options map[string]string `json:"options"`
+}
+
+// newNetworkCreateRule returns a networkCreateRule with its default settings.
+func newNetworkCreateRule() rule {
+	return &networkCreateRule{
+		AllowDrivers: []string{"bridge", "overlay"},
+		Deny: []networkDeny{
+			{Driver: "macvlan", Options: map[string]string{"parent": "*"}},
+			{Driver: "ipvlan", Options: map[string]string{"parent": "*"}},
+			{Driver: "bridge", Options: map[string]string{"com.docker.network.bridge.host_binding_ipv4": "*"}},
+		},
+	}
+}
+
+// Name implements rule for networkCreateRule.
+func (r *networkCreateRule) Name() string {
+	return "network-create"
+}
+
+// Code implements rule for networkCreateRule.
+func (r *networkCreateRule) Code() string {
+	return "DUH-NETWORK-CREATE"
+}
+
+// validate implements validator for networkCreateRule.
+func (r *networkCreateRule) validate() error {
+	r.denySubnets = nil
+	for _, s := range r.DenySubnets {
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("deny-subnets: %v", err)
+		}
+		r.denySubnets = append(r.denySubnets, n)
+	}
+	return nil
+}
+
+// Evaluate implements rule for networkCreateRule.
+func (r *networkCreateRule) Evaluate(ctx *evalContext) decision {
+	if ctx.path() != "/networks/create" {
+		return allow()
+	}
+	driver := getString(ctx.body, "Driver")
+	if driver == "" {
+		driver = "bridge"
+	}
+	options := make(map[string]string)
+	for k, v := range getMap(ctx.body, "Options") {
+		options[k], _ = v.(string)
+	}
+	ctx.logData["Driver"] = driver
+	configs := getSlice(getMap(ctx.body, "IPAM"), "Config")
+	d := r.evaluate(driver, options, configs)
+	if !d.Allow && len(configs) > 0 {
+		// Log the address ranges of denied networks, to see what was
+		// attempted.
+		ctx.logData["IPAM"] = configs
+	}
+	return d
+}
+
+// evaluate checks a network with driver, driver options, and IPAM configs.
+func (r *networkCreateRule) evaluate(driver string, options map[string]string, configs []interface{}) decision {
+	if len(r.AllowDrivers) > 0 && !matchAny(r.AllowDrivers, driver) {
+		return deny("creating %s networks is not allowed, allowed drivers are: %s", driver, strings.Join(r.AllowDrivers, ", "))
+	}
+	for _, d := range r.Deny {
+		if d.matches(driver, options) {
+			return deny("creating %s networks with %s is not allowed", driver, d.describe(options))
+		}
+	}
+	for _, c := range configs {
+		m, _ := c.(map[string]interface{})
+		subnet := getString(m, "Subnet")
+		if subnet == "" {
+			continue
+		}
+		_, n, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return deny("network subnet %s is not a valid CIDR network", subnet)
+		}
+		for _, d := range r.denySubnets {
+			if d.Contains(n.IP) || n.Contains(d.IP) {
+				return deny("network subnet %s overlaps %s, which is not allowed", subnet, d)
+			}
+		}
+	}
+	return allow()
+}
+
+// matches returns true if a network with driver and options matches d.
+func (d networkDeny) matches(driver string, options map[string]string) bool {
+	if d.Driver != "" {
+		if ok, _ := path.Match(d.Driver, driver); !ok {
+			return false
+		}
+	}
+	for k, p := range d.Options {
+		v, ok := options[k]
+		if !ok {
+			return false
+		}
+		if ok, _ := path.Match(p, v); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// describe returns the options of a network matched by d, for messages.
+func (d networkDeny) describe(options map[string]string) string {
+	if len(d.Options) == 0 {
+		return "any options"
+	}
+	var opts []string
+	for k := range d.Options {
+		opts = append(opts, k+"="+options[k])
+	}
+	sort.Strings(opts)
+	return "options " + strings.Join(opts, ", ")
+}
@@ -0,0 +1,50 @@
+package main
+
+// noNewPrivilegesRule denies container creation unless no-new-privileges is
+// set in HostConfig.SecurityOpt.
+type noNewPrivilegesRule struct {
+	ruleOptions
+	exemptions
+}
+
+// newNoNewPrivilegesRule returns a noNewPrivilegesRule with its default
+// settings.
+func newNoNewPrivilegesRule() rule {
+	return &noNewPrivilegesRule{}
+}
+
+// Name implements rule for noNewPrivilegesRule.
+func (r *noNewPrivilegesRule) Name() string {
+	return "no-new-privileges"
+}
+
+// Code implements rule for noNewPrivilegesRule.
+func (r *noNewPrivilegesRule) Code() string {
+	return "DUH-NO-NEW-PRIVILEGES"
+}
+
+// Evaluate implements rule for noNewPrivilegesRule.
+func (r *noNewPrivilegesRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	opts := getSlice(ctx.hostConfig(), "SecurityOpt")
+	if !hasNoNewPrivileges(opts) {
+		return deny("no-new-privileges is required: add --security-opt no-new-privileges")
+	}
+	return allow()
+}
+
+// hasNoNewPrivileges returns true if the supplied SecurityOpt list enables
+// no-new-privileges. Docker clients send this as either
+// "no-new-privileges", "no-new-privileges:true", or "no-new-privileges=true".
+func hasNoNewPrivileges(opts []interface{}) bool {
+	for _, v := range opts {
+		opt, _ := v.(string)
+		switch opt {
+		case "no-new-privileges", "no-new-privileges:true", "no-new-privileges=true":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package main
+
+import "testing"
+
+func TestNoNewPrivilegesRule(t *testing.T) {
+	create := func(image string, opts ...interface{}) authzReq {
+		body := createBody(obj{"SecurityOpt": arr(opts)})
+		body["Image"] = image
+		return newAuthzReq("POST", "/v1.41/containers/create", body)
+	}
+	labeled := createBody(nil)
+	labeled["Labels"] = obj{"setuid": "yes"}
+	p := testPolicy(t, "no-new-privileges.enabled=true", "no-new-privileges.exempt-images=sudo-*", "no-new-privileges.exempt-labels=setuid=yes")
+	runRuleCases(t, p, []ruleCase{
+		{"bare", create("busybox", "no-new-privileges"), true, ""},
+		{"colon", create("busybox", "no-new-privileges:true"), true, ""},
+		{"equals", create("busybox", "label=disable", "no-new-privileges=true"), true, ""},
+		{"missing", create("busybox", "apparmor=unconfined"), false, "no-new-privileges is required: add --security-opt no-new-privileges"},
+		{"false", create("busybox", "no-new-privileges=false"), false, ""},
+		{"colon false", create("busybox", "no-new-privileges:false"), false, ""},
+		{"no HostConfig", newAuthzReq("POST", "/containers/create", createBody(nil)), false, ""},
+		{"not a string", create("busybox", true), false, ""},
+		{"SecurityOpt not a list", newAuthzReq("POST", "/containers/create", createBody(obj{"SecurityOpt": "no-new-privileges"})), false, ""},
+		{"exempt image", create("sudo-tools:1", "label=disable"), true, ""},
+		{"exempt label", newAuthzReq("POST", "/containers/create", labeled), true, ""},
+		{"not a create", newAuthzReq("POST", "/containers/abc/start", nil), true, ""},
+	})
+}
@@ -0,0 +1,38 @@
+package main
+
+// nonRootUserRule denies container creation when the container would run as
+// root, because the top-level User of the request is unset, root, or UID 0.
+type nonRootUserRule struct {
+	ruleOptions
+	exemptions
+}
+
+// newNonRootUserRule returns a nonRootUserRule with its default settings.
+func newNonRootUserRule() rule {
+	return &nonRootUserRule{}
+}
+
+// Name implements rule for nonRootUserRule.
+func (r *nonRootUserRule) Name() string {
+	return "non-root-user"
+}
+
+// Code implements rule for nonRootUserRule.
+func (r *nonRootUserRule) Code() string {
+	return "DUH-ROOT-USER"
+}
+
+// Evaluate implements rule for nonRootUserRule.
+func (r *nonRootUserRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	user := getString(ctx.body, "User")
+	if user == "" {
+		return deny("a non-root user is required: set USER in the image, or add --user")
+	}
+	if isRootUser(user) {
+		return deny("--user=%s runs as root, a non-root user is required", user)
+	}
+	return allow()
+}
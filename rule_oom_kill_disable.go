@@ -0,0 +1,53 @@
+package main
+
+// oomKillDisableRule denies container creation when HostConfig.OomKillDisable
+// is set. By default this is only denied on containers with no memory limit,
+// as the OOM killer is then the only thing standing between the container
+// and the host's memory.
+type oomKillDisableRule struct {
+	ruleOptions
+
+	// AllowWithMemoryLimit allows OomKillDisable on containers that have a
+	// memory limit set.
+	AllowWithMemoryLimit bool `json:"allow-with-memory-limit"`
+}
+
+// newOomKillDisableRule returns an oomKillDisableRule with its default
+// settings.
+func newOomKillDisableRule() rule {
+	return &oomKillDisableRule{
+		AllowWithMemoryLimit: true,
+	}
+}
+
+// Name implements rule for oomKillDisableRule.
+func (r *oomKillDisableRule) Name() string {
+	return "oom-kill-disable"
+}
+
+// Code implements rule for oomKillDisableRule.
+func (r *oomKillDisableRule) Code() string {
+	return "DUH-OOM-KILL-DISABLE"
+}
+
+// Evaluate implements rule for oomKillDisableRule.
+func (r *oomKillDisableRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	disabled := getBool(hc, "OomKillDisable")
+	if !disabled {
+		return allow()
+	}
+	if !r.AllowWithMemoryLimit {
+		ctx.logData["OomKillDisable"] = disabled
+		return deny("--oom-kill-disable is not allowed")
+	}
+	if memory, _ := toInt64(hc["Memory"]); memory <= 0 {
+		ctx.logData["OomKillDisable"] = disabled
+		ctx.logData["Memory"] = memory
+		return deny("OomKillDisable=true is not allowed without a memory limit (Memory=%d): add --memory", memory)
+	}
+	return allow()
+}
@@ -0,0 +1,39 @@
+package main
+
+// oomScoreAdjRule denies container creation when HostConfig.OomScoreAdj is
+// below a minimum, protecting the container from the OOM killer at the
+// expense of the rest of the host.
+type oomScoreAdjRule struct {
+	ruleOptions
+
+	// Min is the lowest allowed OOM score adjustment.
+	Min int64 `json:"min"`
+}
+
+// newOomScoreAdjRule returns an oomScoreAdjRule with its default settings.
+func newOomScoreAdjRule() rule {
+	return &oomScoreAdjRule{
+		Min: -500,
+	}
+}
+
+// Name implements rule for oomScoreAdjRule.
+func (r *oomScoreAdjRule) Name() string {
+	return "oom-score-adj"
+}
+
+// Code implements rule for oomScoreAdjRule.
+func (r *oomScoreAdjRule) Code() string {
+	return "DUH-OOM-SCORE-ADJ"
+}
+
+// Evaluate implements rule for oomScoreAdjRule.
+func (r *oomScoreAdjRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	if v, ok := toInt64(ctx.hostConfig()["OomScoreAdj"]); ok && v < r.Min {
+		return deny("--oom-score-adj=%d is below the minimum of %d", v, r.Min)
+	}
+	return allow()
+}
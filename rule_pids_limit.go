@@ -0,0 +1,73 @@
+package main
+
+// pidsLimitRule denies container creation unless HostConfig.PidsLimit is set
+// to a positive value, optionally no larger than a maximum.
+type pidsLimitRule struct {
+	ruleOptions
+	exemptions
+
+	// Max is the largest allowed PID limit. Zero allows any positive limit.
+	Max int64 `json:"max"`
+}
+
+// newPidsLimitRule returns a pidsLimitRule with its default settings.
+func newPidsLimitRule() rule {
+	return &pidsLimitRule{
+		Max: 4096,
+	}
+}
+
+// Name implements rule for pidsLimitRule.
+func (r *pidsLimitRule) Name() string {
+	return "pids-limit"
+}
+
+// Code implements rule for pidsLimitRule.
+func (r *pidsLimitRule) Code() string {
+	return "DUH-PIDS-LIMIT"
+}
+
+// Evaluate implements rule for pidsLimitRule.
+func (r *pidsLimitRule) Evaluate(ctx *evalContext) decision {
+	if id, ok := ctx.updateContainer(); ok {
+		return r.evaluateUpdate(ctx, id)
+	}
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	// Depending on the API version, no limit is sent as 0, -1, or null. A
+	// value that isn't a whole number is treated the same as no limit.
+	raw := ctx.hostConfig()["PidsLimit"]
+	v, _ := toInt64(raw)
+	problem := "a PID limit is required"
+	if raw != nil {
+		problem = "an unlimited PID limit is not allowed"
+	}
+	switch {
+	case v <= 0 && r.Max > 0:
+		return deny("%s: add --pids-limit with a value between 1 and %d", problem, r.Max)
+	case v <= 0:
+		return deny("%s: add --pids-limit with a positive value", problem)
+	case r.Max > 0 && v > r.Max:
+		return deny("--pids-limit=%d exceeds the maximum of %d", v, r.Max)
+	}
+	return allow()
+}
+
+// evaluateUpdate checks a docker update of container id. A PidsLimit that is
+// null leaves the current limit unchanged, and 0 or -1 removes it.
+func (r *pidsLimitRule) evaluateUpdate(ctx *evalContext, id string) decision {
+	ctx.logData["ContainerID"] = id
+	raw := ctx.body["PidsLimit"]
+	if raw == nil {
+		return allow()
+	}
+	v, _ := toInt64(raw)
+	switch {
+	case v <= 0:
+		return deny("removing the PID limit is not allowed")
+	case r.Max > 0 && v > r.Max:
+		return deny("--pids-limit=%d exceeds the maximum of %d", v, r.Max)
+	}
+	return allow()
+}
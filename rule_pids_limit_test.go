@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestPidsLimitRule(t *testing.T) {
+	create := func(hc obj) authzReq {
+		return newAuthzReq("POST", "/containers/create", createBody(hc))
+	}
+	runRuleCases(t, testPolicy(t, "pids-limit.enabled=true"), []ruleCase{
+		{"unset", create(nil), false, "a PID limit is required: add --pids-limit with a value between 1 and 4096"},
+		{"null", create(obj{"PidsLimit": nil}), false, "a PID limit is required: add --pids-limit with a value between 1 and 4096"},
+		{"zero", create(obj{"PidsLimit": 0}), false, "an unlimited PID limit is not allowed: add --pids-limit with a value between 1 and 4096"},
+		{"unlimited", create(obj{"PidsLimit": -1}), false, ""},
+		{"within max", create(obj{"PidsLimit": 100}), true, ""},
+		{"over max", create(obj{"PidsLimit": 5000}), false, "--pids-limit=5000 exceeds the maximum of 4096"},
+		{"not a number", create(obj{"PidsLimit": "100"}), false, ""},
+
+		{"update unchanged", updateReq(obj{"Memory": 1 << 30}), true, ""},
+		{"update within max", updateReq(obj{"PidsLimit": 200}), true, ""},
+		{"update over max", updateReq(obj{"PidsLimit": 8192}), false, "--pids-limit=8192 exceeds the maximum of 4096"},
+		{"update removes limit", updateReq(obj{"PidsLimit": 0}), false, "removing the PID limit is not allowed"},
+		{"update unlimited", updateReq(obj{"PidsLimit": -1}), false, "removing the PID limit is not allowed"},
+	})
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// platformRule denies container creation and image pulls for platforms other
+// than the allowed ones, ie: foreign architecture images run with qemu.
+type platformRule struct {
+	ruleOptions
+
+	// Allow is a list of allowed platforms, in the form os/arch[/variant], ie:
+	// linux/amd64 or linux/arm/v7. Entries without a variant allow any
+	// variant. An empty list allows any platform.
+	Allow []string `json:"allow"`
+
+	allow []platform
+}
+
+// platform is an os/arch/variant platform, as given in the platform query
+// parameter.
+type platform struct {
+	OS      string
+	Arch    string
+	Variant string
+}
+
+// platformArchAliases maps alternative architecture names accepted by the
+// daemon to their normalized architecture and variant.
+var platformArchAliases = map[string]platform{
+	"x86_64":  {Arch: "amd64"},
+	"x86-64":  {Arch: "amd64"},
+	"aarch64": {Arch: "arm64"},
+	"armhf":   {Arch: "arm", Variant: "v7"},
+	"armel":   {Arch: "arm", Variant: "v6"},
+	"i386":    {Arch: "386"},
+}
+
+// platformOSes are the operating systems recognized when a platform has a
+// single component, which is otherwise an architecture.
+var platformOSes = []string{"linux", "windows", "darwin", "freebsd"}
+
+// newPlatformRule returns a platformRule with its default settings.
+func newPlatformRule() rule {
+	return &platformRule{}
+}
+
+// Name implements rule for platformRule.
+func (r *platformRule) Name() string {
+	return "platform"
+}
+
+// Code implements rule for platformRule.
+func (r *platformRule) Code() string {
+	return "DUH-PLATFORM"
+}
+
+// validate implements validator for platformRule.
+func (r *platformRule) validate() error {
+	r.allow = nil
+	for _, s := range r.Allow {
+		p, err := parsePlatform(s)
+		if err != nil {
+			return fmt.Errorf("allow: %v", err)
+		}
+		r.allow = append(r.allow, p)
+	}
+	return nil
+}
+
+// Evaluate implements rule for platformRule.
+func (r *platformRule) Evaluate(ctx *evalContext) decision {
+	if p := ctx.path(); p != "/containers/create" && p != "/images/create" || len(r.allow) == 0 {
+		return allow()
+	}
+	s := ctx.query().Get("platform")
+	p := platform{OS: runtime.GOOS, Arch: runtime.GOARCH}
+	if s != "" {
+		var err error
+		if p, err = parsePlatform(s); err != nil {
+			return deny("platform %q is malformed", s)
+		}
+		ctx.logData["Platform"] = p.String()
+	}
+	for _, a := range r.allow {
+		if a.OS == p.OS && a.Arch == p.Arch && (a.Variant == "" || a.Variant == p.Variant) {
+			return allow()
+		}
+	}
+	return deny("platform %s is not allowed, allowed platforms are: %s", p, strings.Join(r.Allow, ", "))
+}
+
+// parsePlatform parses a platform in the form os[/arch[/variant]], the same
+// way as the daemon: names are case-insensitive, architecture aliases such as
+// x86_64 are normalized, and a single component is either an operating system
+// for the native architecture, or an architecture for linux.
+func parsePlatform(s string) (platform, error) {
+	parts := strings.Split(strings.ToLower(strings.TrimSpace(s)), "/")
+	for _, part := range parts {
+		if part == "" {
+			return platform{}, fmt.Errorf("invalid platform %q", s)
+		}
+	}
+	var p platform
+	switch len(parts) {
+	case 1:
+		if inList(platformOSes, parts[0]) {
+			p = platform{OS: parts[0], Arch: runtime.GOARCH}
+		} else {
+			p = platform{OS: "linux", Arch: parts[0]}
+		}
+	case 2:
+		p = platform{OS: parts[0], Arch: parts[1]}
+	case 3:
+		p = platform{OS: parts[0], Arch: parts[1], Variant: parts[2]}
+	default:
+		return platform{}, fmt.Errorf("invalid platform %q", s)
+	}
+	if a, ok := platformArchAliases[p.Arch]; ok {
+		p.Arch = a.Arch
+		if p.Variant == "" {
+			p.Variant = a.Variant
+		}
+	}
+	return p, nil
+}
+
+// String returns the platform in the form os/arch[/variant].
+func (p platform) String() string {
+	s := p.OS + "/" + p.Arch
+	if p.Variant != "" {
+		s += "/" + p.Variant
+	}
+	return s
+}
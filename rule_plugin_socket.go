@@ -0,0 +1,47 @@
+package main
+
+import "path/filepath"
+
+// pluginSocketRule denies container creation when the directory holding the
+// plugin socket is mounted into the container, as that would allow the
+// container to tamper with the sockets of authorization plugins, including
+// this one.
+type pluginSocketRule struct {
+	ruleOptions
+}
+
+// newPluginSocketRule returns a pluginSocketRule with its default settings.
+func newPluginSocketRule() rule {
+	return &pluginSocketRule{
+		ruleOptions: ruleOptions{Enabled: true},
+	}
+}
+
+// Name implements rule for pluginSocketRule.
+func (r *pluginSocketRule) Name() string {
+	return "plugin-socket"
+}
+
+// Code implements rule for pluginSocketRule.
+func (r *pluginSocketRule) Code() string {
+	return "DUH-PLUGIN-SOCKET"
+}
+
+// Evaluate implements rule for pluginSocketRule.
+func (r *pluginSocketRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	dirs := resolvePath(filepath.Dir(socketPath))
+	for _, src := range ctx.hostSources() {
+		for _, p := range resolvePath(src) {
+			for _, dir := range dirs {
+				// Mounting any parent of the plugin directory exposes it too.
+				if pathHasPrefix(p, dir) || pathHasPrefix(dir, p) || p == "/" {
+					return deny("mounting %s is not allowed, as it exposes the plugin socket directory %s", src, dir)
+				}
+			}
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// pluginsRule denies installing and enabling Docker plugins, which run with
+// privileges that container policy doesn't cover, and can include other
+// authorization plugins.
+type pluginsRule struct {
+	ruleOptions
+
+	// Allow is a list of glob patterns for the remote references that plugins
+	// may still be pulled or upgraded from, ie:
+	// registry.example.com/plugins/*. An empty list denies all plugins.
+	Allow []string `json:"allow"`
+
+	// AllowCreate allows creating plugins from a local rootfs and config,
+	// which have no remote reference to check against Allow.
+	AllowCreate bool `json:"allow-create"`
+
+	// AllowEnable allows enabling installed plugins. Plugins are enabled by
+	// their local name, which can be any --alias given at install time, so
+	// it can't be checked against Allow.
+	AllowEnable bool `json:"allow-enable"`
+}
+
+// newPluginsRule returns a pluginsRule with its default settings.
+func newPluginsRule() rule {
+	return &pluginsRule{}
+}
+
+// Name implements rule for pluginsRule.
+func (r *pluginsRule) Name() string {
+	return "plugins"
+}
+
+// Code implements rule for pluginsRule.
+func (r *pluginsRule) Code() string {
+	return "DUH-PLUGIN"
+}
+
+// Evaluate implements rule for pluginsRule.
+func (r *pluginsRule) Evaluate(ctx *evalContext) decision {
+	if ctx.req.RequestMethod != "POST" {
+		return allow()
+	}
+	q := ctx.query()
+	var action, ref string
+	// Plugin names can have slashes, ie: /plugins/example/plugin:1.0/enable.
+	name := strings.TrimPrefix(ctx.path(), "/plugins/")
+	switch {
+	case name == ctx.path():
+		return allow()
+	case name == "pull":
+		action, ref = "installing", q.Get("remote")
+	case name == "create":
+		ctx.logData["Plugin"] = q.Get("name")
+		if r.AllowCreate {
+			return allow()
+		}
+		return deny("creating plugin %s is not allowed", q.Get("name"))
+	case strings.HasSuffix(name, "/enable"):
+		ctx.logData["Plugin"] = strings.TrimSuffix(name, "/enable")
+		if r.AllowEnable {
+			return allow()
+		}
+		return deny("enabling plugin %s is not allowed", strings.TrimSuffix(name, "/enable"))
+	case strings.HasSuffix(name, "/upgrade"):
+		action, ref = "upgrading", q.Get("remote")
+	default:
+		return allow()
+	}
+	ctx.logData["Plugin"] = ref
+	_, normalized := normalizeImage(ref)
+	if ref != "" && (imageInList(ref, r.Allow) || imageInList(normalized, r.Allow)) {
+		return allow()
+	}
+	var privileges []interface{}
+	if json.Unmarshal(ctx.req.RequestBody, &privileges) == nil && len(privileges) > 0 {
+		ctx.logData["Privileges"] = privileges
+	}
+	if len(r.Allow) == 0 {
+		return deny("%s plugin %s is not allowed", action, ref)
+	}
+	return deny("%s plugin %s is not allowed, allowed plugins are: %s", action, ref, strings.Join(r.Allow, ", "))
+}
@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestPluginsRule(t *testing.T) {
+	privileges := arr{obj{"Name": "network", "Value": arr{"host"}}}
+	cases := []ruleCase{
+		{"list", newAuthzReq("GET", "/plugins", nil), true, ""},
+		{"pull allowed", newAuthzReq("POST", "/v1.41/plugins/pull?remote=registry.example.com/plugins/sshfs:latest", privileges), true, ""},
+		{"pull allowed by alias", newAuthzReq("POST", "/v1.41/plugins/pull?remote=registry.example.com/plugins/sshfs&name=sshfs", privileges), true, ""},
+		{"pull denied", newAuthzReq("POST", "/v1.41/plugins/pull?remote=vieux/sshfs&name=registry.example.com/plugins/sshfs", privileges), false, "installing plugin vieux/sshfs is not allowed, allowed plugins are: registry.example.com/plugins/*"},
+		{"pull without remote", newAuthzReq("POST", "/plugins/pull", nil), false, ""},
+		{"upgrade allowed", newAuthzReq("POST", "/plugins/vieux/sshfs:latest/upgrade?remote=registry.example.com/plugins/sshfs:2", privileges), true, ""},
+		{"upgrade denied", newAuthzReq("POST", "/plugins/registry.example.com/plugins/sshfs:latest/upgrade?remote=vieux/sshfs:2", privileges), false, "upgrading plugin vieux/sshfs:2 is not allowed, allowed plugins are: registry.example.com/plugins/*"},
+		{"create", newAuthzReq("POST", "/plugins/create?name=registry.example.com/plugins/local", nil), false, "creating plugin registry.example.com/plugins/local is not allowed"},
+		{"enable", newAuthzReq("POST", "/plugins/vieux/sshfs:latest/enable?timeout=0", nil), false, "enabling plugin vieux/sshfs:latest is not allowed"},
+		{"enable allowed name", newAuthzReq("POST", "/plugins/registry.example.com/plugins/sshfs:latest/enable", nil), false, "enabling plugin registry.example.com/plugins/sshfs:latest is not allowed"},
+		{"disable", newAuthzReq("POST", "/plugins/vieux/sshfs:latest/disable", nil), true, ""},
+	}
+	runRuleCases(t, testPolicy(t, "plugins.enabled=true", "plugins.allow=registry.example.com/plugins/*"), cases)
+
+	runRuleCases(t, testPolicy(t, "plugins.enabled=true"), []ruleCase{
+		{"empty allow", newAuthzReq("POST", "/plugins/pull?remote=registry.example.com/plugins/sshfs", privileges), false, "installing plugin registry.example.com/plugins/sshfs is not allowed"},
+	})
+	runRuleCases(t, testPolicy(t, "plugins.enabled=true", "plugins.allow-create=true"), []ruleCase{
+		{"create allowed", newAuthzReq("POST", "/plugins/create?name=local", nil), true, ""},
+	})
+	runRuleCases(t, testPolicy(t, "plugins.enabled=true", "plugins.allow-enable=true"), []ruleCase{
+		{"enable allowed", newAuthzReq("POST", "/plugins/vieux/sshfs:latest/enable", nil), true, ""},
+		{"create still denied", newAuthzReq("POST", "/plugins/create?name=local", nil), false, "creating plugin local is not allowed"},
+	})
+}
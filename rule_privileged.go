@@ -0,0 +1,47 @@
+package main
+
+// privilegedGrants is a static description of what --privileged gives a
+// container, logged when the privileged rule denies a request so that
+// reviewers have that context without needing to know Docker internals.
+var privilegedGrants = map[string]interface{}{
+	"Capabilities":  allCaps,
+	"Devices":       "all host devices, with device cgroup rule a *:* rwm",
+	"Seccomp":       "unconfined",
+	"AppArmor":      "unconfined",
+	"SELinux":       "unconfined (label=disable)",
+	"MaskedPaths":   "none",
+	"ReadonlyPaths": "none; /sys and cgroups are mounted read-write",
+}
+
+// privilegedRule denies container creation with HostConfig.Privileged set.
+type privilegedRule struct {
+	ruleOptions
+	exemptions
+}
+
+// newPrivilegedRule returns a privilegedRule with its default settings.
+func newPrivilegedRule() rule {
+	return &privilegedRule{}
+}
+
+// Name implements rule for privilegedRule.
+func (r *privilegedRule) Name() string {
+	return "privileged"
+}
+
+// Code implements rule for privilegedRule.
+func (r *privilegedRule) Code() string {
+	return "DUH-PRIVILEGED"
+}
+
+// Evaluate implements rule for privilegedRule.
+func (r *privilegedRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	if getBool(ctx.hostConfig(), "Privileged") {
+		ctx.logData["PrivilegedGrants"] = privilegedGrants
+		return deny("--privileged is not allowed")
+	}
+	return allow()
+}
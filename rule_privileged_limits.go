@@ -0,0 +1,49 @@
+package main
+
+import "strings"
+
+// privilegedLimitsRule denies creation of privileged containers that don't
+// also set memory and CPU limits, as a privileged container without limits
+// can easily starve the host.
+type privilegedLimitsRule struct {
+	ruleOptions
+	exemptions
+}
+
+// newPrivilegedLimitsRule returns a privilegedLimitsRule with its default
+// settings.
+func newPrivilegedLimitsRule() rule {
+	return &privilegedLimitsRule{}
+}
+
+// Name implements rule for privilegedLimitsRule.
+func (r *privilegedLimitsRule) Name() string {
+	return "privileged-limits"
+}
+
+// Code implements rule for privilegedLimitsRule.
+func (r *privilegedLimitsRule) Code() string {
+	return "DUH-PRIVILEGED-LIMITS"
+}
+
+// Evaluate implements rule for privilegedLimitsRule.
+func (r *privilegedLimitsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	hc := ctx.hostConfig()
+	if !getBool(hc, "Privileged") {
+		return allow()
+	}
+	var missing []string
+	if m, _ := toInt64(hc["Memory"]); m <= 0 {
+		missing = append(missing, "--memory")
+	}
+	if cpuLimit(hc) == 0 {
+		missing = append(missing, "--cpus")
+	}
+	if len(missing) > 0 {
+		return deny("privileged containers must set resource limits, add: %s", strings.Join(missing, ", "))
+	}
+	return allow()
+}
@@ -0,0 +1,35 @@
+package main
+
+// publishAllPortsRule denies container creation when HostConfig.PublishAllPorts
+// is set, which publishes every exposed port on an ephemeral host port.
+type publishAllPortsRule struct {
+	ruleOptions
+	exemptions
+}
+
+// newPublishAllPortsRule returns a publishAllPortsRule with its default
+// settings.
+func newPublishAllPortsRule() rule {
+	return &publishAllPortsRule{}
+}
+
+// Name implements rule for publishAllPortsRule.
+func (r *publishAllPortsRule) Name() string {
+	return "publish-all-ports"
+}
+
+// Code implements rule for publishAllPortsRule.
+func (r *publishAllPortsRule) Code() string {
+	return "DUH-PUBLISH-ALL-PORTS"
+}
+
+// Evaluate implements rule for publishAllPortsRule.
+func (r *publishAllPortsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	if getBool(ctx.hostConfig(), "PublishAllPorts") {
+		return deny("publishing all exposed ports (-P) is not allowed: publish the ports you need with -p instead")
+	}
+	return allow()
+}
@@ -0,0 +1,35 @@
+package main
+
+// readonlyRootfsRule denies container creation unless HostConfig.ReadonlyRootfs
+// is true.
+type readonlyRootfsRule struct {
+	ruleOptions
+	exemptions
+}
+
+// newReadonlyRootfsRule returns a readonlyRootfsRule with its default
+// settings.
+func newReadonlyRootfsRule() rule {
+	return &readonlyRootfsRule{}
+}
+
+// Name implements rule for readonlyRootfsRule.
+func (r *readonlyRootfsRule) Name() string {
+	return "readonly-rootfs"
+}
+
+// Code implements rule for readonlyRootfsRule.
+func (r *readonlyRootfsRule) Code() string {
+	return "DUH-READONLY-ROOTFS"
+}
+
+// Evaluate implements rule for readonlyRootfsRule.
+func (r *readonlyRootfsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	if !getBool(ctx.hostConfig(), "ReadonlyRootfs") {
+		return deny("a read-only root filesystem is required: add --read-only, and --tmpfs for writable scratch space (ie: --tmpfs /tmp)")
+	}
+	return allow()
+}
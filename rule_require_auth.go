@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// requireAuthRule denies requests to sensitive endpoints from clients that
+// have not authenticated with a TLS client certificate.
+type requireAuthRule struct {
+	ruleOptions
+
+	// AuthEndpoints is a list of API endpoints that require authentication.
+	// Endpoints are matched against the request path with the query string
+	// and any API version prefix removed.
+	AuthEndpoints []string `json:"auth-endpoints"`
+}
+
+// defaultAuthEndpoints are the endpoints that require authentication unless
+// the policy lists others.
+var defaultAuthEndpoints = []string{"/containers/create"}
+
+// newRequireAuthRule returns a requireAuthRule with its default settings.
+func newRequireAuthRule() rule {
+	return &requireAuthRule{
+		AuthEndpoints: append([]string(nil), defaultAuthEndpoints...),
+	}
+}
+
+// Name implements rule for requireAuthRule.
+func (r *requireAuthRule) Name() string {
+	return "require-auth"
+}
+
+// Code implements rule for requireAuthRule.
+func (r *requireAuthRule) Code() string {
+	return "DUH-REQUIRE-AUTH"
+}
+
+// validate implements validator for requireAuthRule.
+func (r *requireAuthRule) validate() error {
+	// AuthEndpoints used to be named endpoints, hiding the scope setting of
+	// the same name. Policies written then are still read the same way, so
+	// endpoints is an alias for auth-endpoints here rather than a scope.
+	if len(r.Endpoints) == 0 {
+		return nil
+	}
+	if len(r.AuthEndpoints) > 0 && !reflect.DeepEqual(r.AuthEndpoints, defaultAuthEndpoints) {
+		return errors.New("endpoints is the old name for auth-endpoints, set only auth-endpoints")
+	}
+	log.Warnf("Setting endpoints for rule require-auth is the old name for auth-endpoints, use auth-endpoints instead")
+	r.AuthEndpoints, r.Endpoints = r.Endpoints, nil
+	return nil
+}
+
+// Evaluate implements rule for requireAuthRule.
+func (r *requireAuthRule) Evaluate(ctx *evalContext) decision {
+	if ctx.req.authenticated() {
+		return allow()
+	}
+	for _, e := range r.AuthEndpoints {
+		if ctx.path() == apiPath(e) {
+			return deny("authentication is required for %s", e)
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestRequireAuthRule(t *testing.T) {
+	authed := func(method, uri string) authzReq {
+		req := newAuthzReq(method, uri, nil)
+		req.User, req.UserAuthNMethod = "alice", "TLS"
+		return req
+	}
+	cases := []ruleCase{
+		{"create", newAuthzReq("POST", "/v1.41/containers/create?name=foo", createBody(nil)), false, "authentication is required for /containers/create"},
+		{"create authenticated", authed("POST", "/v1.41/containers/create"), true, ""},
+		{"other endpoint", newAuthzReq("GET", "/v1.41/containers/json", nil), true, ""},
+	}
+	runRuleCases(t, testPolicy(t, "require-auth.enabled=true"), cases)
+
+	exec := []ruleCase{
+		{"exec", newAuthzReq("POST", "/v1.41/containers/abc/exec", obj{"Cmd": arr{"sh"}}), false, "authentication is required for /containers/abc/exec"},
+		{"create", newAuthzReq("POST", "/v1.41/containers/create", createBody(nil)), true, ""},
+	}
+	t.Run("auth-endpoints", func(t *testing.T) {
+		runRuleCases(t, testPolicy(t, "require-auth.enabled=true", "require-auth.auth-endpoints=/containers/abc/exec"), exec)
+	})
+	t.Run("old endpoints name", func(t *testing.T) {
+		runRuleCases(t, testPolicyFile(t, `{"rules": {"require-auth": {"enabled": true, "endpoints": ["/containers/abc/exec"]}}}`), exec)
+	})
+	t.Run("scope", func(t *testing.T) {
+		runRuleCases(t, testPolicy(t, "require-auth.enabled=true", "require-auth.methods=PUT"), []ruleCase{
+			{"POST not in scope", newAuthzReq("POST", "/containers/create", createBody(nil)), true, ""},
+		})
+	})
+}
+
+func TestRequireAuthBothEndpoints(t *testing.T) {
+	var l settingList
+	l.Set("require-auth.endpoints=/containers/create")
+	l.Set("require-auth.auth-endpoints=/images/create")
+	if _, err := loadPolicy("", l); err == nil {
+		t.Fatal("expected an error with both endpoints and auth-endpoints set")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// requiredLabelsRule denies container creation when labels are missing, or
+// have values that don't match a pattern.
+type requiredLabelsRule struct {
+	ruleOptions
+	exemptions
+
+	// Require is a list of label keys that must be set.
+	Require []string `json:"require"`
+
+	// Match maps label keys to regular expressions that their values must
+	// match in full, ie: "cost-center": "CC-\\d{4}". Keys in Match must also
+	// be set.
+	Match map[string]string `json:"match"`
+
+	match map[string]*regexp.Regexp
+}
+
+// newRequiredLabelsRule returns a requiredLabelsRule with its default
+// settings.
+func newRequiredLabelsRule() rule {
+	return &requiredLabelsRule{}
+}
+
+// Name implements rule for requiredLabelsRule.
+func (r *requiredLabelsRule) Name() string {
+	return "required-labels"
+}
+
+// Code implements rule for requiredLabelsRule.
+func (r *requiredLabelsRule) Code() string {
+	return "DUH-REQUIRED-LABELS"
+}
+
+// validate implements validator for requiredLabelsRule.
+func (r *requiredLabelsRule) validate() error {
+	r.match = make(map[string]*regexp.Regexp)
+	for k, expr := range r.Match {
+		re, err := regexp.Compile("^(?:" + expr + ")$")
+		if err != nil {
+			return fmt.Errorf("match: %s: %v", k, err)
+		}
+		r.match[k] = re
+	}
+	return nil
+}
+
+// Evaluate implements rule for requiredLabelsRule.
+func (r *requiredLabelsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	labels := getMap(ctx.body, "Labels")
+	keys := append([]string{}, r.Require...)
+	for k := range r.match {
+		if !inList(keys, k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var missing, invalid []string
+	for _, k := range keys {
+		v, ok := labels[k].(string)
+		switch {
+		case !ok:
+			missing = append(missing, k)
+		case r.match[k] != nil && !r.match[k].MatchString(v):
+			invalid = append(invalid, fmt.Sprintf("%s=%s (must match %s)", k, v, r.Match[k]))
+		}
+	}
+	var problems []string
+	if len(missing) > 0 {
+		problems = append(problems, "missing labels: "+strings.Join(missing, ", "))
+	}
+	if len(invalid) > 0 {
+		problems = append(problems, "invalid labels: "+strings.Join(invalid, ", "))
+	}
+	if len(problems) > 0 {
+		return deny("container labels do not meet policy, %s", strings.Join(problems, "; "))
+	}
+	return allow()
+}
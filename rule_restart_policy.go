@@ -0,0 +1,60 @@
+package main
+
+import "strings"
+
+// restartPolicyRule denies container creation when HostConfig.RestartPolicy
+// is not one of the allowed restart policies, or retries too many times.
+type restartPolicyRule struct {
+	ruleOptions
+	exemptions
+
+	// Allow is a list of allowed restart policy names. The daemon default (no
+	// restart policy given) is always allowed.
+	Allow []string `json:"allow"`
+
+	// MaxRetries caps MaximumRetryCount for the on-failure policy. Zero
+	// disables the cap.
+	MaxRetries int64 `json:"max-retries"`
+}
+
+// newRestartPolicyRule returns a restartPolicyRule with its default settings.
+func newRestartPolicyRule() rule {
+	return &restartPolicyRule{
+		Allow: []string{"no", "on-failure"},
+	}
+}
+
+// Name implements rule for restartPolicyRule.
+func (r *restartPolicyRule) Name() string {
+	return "restart-policy"
+}
+
+// Code implements rule for restartPolicyRule.
+func (r *restartPolicyRule) Code() string {
+	return "DUH-RESTART-POLICY"
+}
+
+// Evaluate implements rule for restartPolicyRule.
+func (r *restartPolicyRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	rp := getMap(ctx.hostConfig(), "RestartPolicy")
+	name := getString(rp, "Name")
+	if name == "" {
+		return allow()
+	}
+	if !inList(r.Allow, name) {
+		return deny("--restart=%s is not allowed, allowed restart policies are: %s", name, strings.Join(r.Allow, ", "))
+	}
+	if name == "on-failure" && r.MaxRetries > 0 {
+		n, _ := toInt64(rp["MaximumRetryCount"])
+		if n == 0 {
+			return deny("--restart=on-failure without a retry count is not allowed, add one of at most %d, ie: --restart=on-failure:%d", r.MaxRetries, r.MaxRetries)
+		}
+		if n > r.MaxRetries {
+			return deny("--restart=on-failure:%d is not allowed, the maximum retry count is %d", n, r.MaxRetries)
+		}
+	}
+	return allow()
+}
@@ -0,0 +1,66 @@
+package main
+
+import "strings"
+
+// runtimeRule denies container creation when HostConfig.Runtime is not one of
+// the allowed OCI runtimes, or is not the required runtime.
+type runtimeRule struct {
+	ruleOptions
+	exemptions
+
+	// Allow is a list of allowed runtimes for --runtime. An empty list allows
+	// any runtime.
+	Allow []string `json:"allow"`
+
+	// DenyDefault denies containers that do not set a runtime, and so use the
+	// daemon's default.
+	DenyDefault bool `json:"deny-default"`
+
+	// Require is a runtime that containers must explicitly use, ie: kata.
+	// When set, Allow is ignored.
+	Require string `json:"require"`
+}
+
+// newRuntimeRule returns a runtimeRule with its default settings.
+func newRuntimeRule() rule {
+	return &runtimeRule{
+		Allow: []string{"runc"},
+	}
+}
+
+// Name implements rule for runtimeRule.
+func (r *runtimeRule) Name() string {
+	return "runtime"
+}
+
+// Code implements rule for runtimeRule.
+func (r *runtimeRule) Code() string {
+	return "DUH-RUNTIME"
+}
+
+// Evaluate implements rule for runtimeRule.
+func (r *runtimeRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	runtime := getString(ctx.hostConfig(), "Runtime")
+	if r.Require != "" {
+		if runtime != r.Require {
+			return deny("--runtime=%s is required", r.Require)
+		}
+		return allow()
+	}
+	if runtime == "" {
+		if r.DenyDefault && len(r.Allow) > 0 {
+			return deny("a runtime is required: add --runtime with one of: %s", strings.Join(r.Allow, ", "))
+		}
+		if r.DenyDefault {
+			return deny("a runtime is required: add --runtime")
+		}
+		return allow()
+	}
+	if len(r.Allow) > 0 && !inList(r.Allow, runtime) {
+		return deny("--runtime=%s is not allowed, allowed runtimes are: %s", runtime, strings.Join(r.Allow, ", "))
+	}
+	return allow()
+}
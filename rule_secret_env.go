@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// redactedValue replaces the values of redacted environment variables in the
+// logs.
+const redactedValue = "<redacted>"
+
+// secretEnvRule denies container creation when Env has variables that look
+// like secrets, by name or value, along with image builds with build args that
+// do. Values are never logged or included in messages.
+type secretEnvRule struct {
+	ruleOptions
+	exemptions
+
+	// Names is a list of regular expressions for the names of variables that
+	// hold secrets.
+	Names []string `json:"names"`
+
+	// Values is a list of regular expressions for variable values that look
+	// like secrets.
+	Values []string `json:"values"`
+
+	// Allow is a list of exact variable names that are never considered
+	// secrets, for false positives.
+	Allow []string `json:"allow"`
+
+	// Redact replaces the values of matching variables with "<redacted>" in
+	// the Env logged with every request, even when the rule is disabled.
+	Redact bool `json:"redact"`
+
+	// BuildArgs also checks the build args of image builds, which end up in
+	// the image history.
+	BuildArgs bool `json:"build-args"`
+
+	names  []*regexp.Regexp
+	values []*regexp.Regexp
+}
+
+// newSecretEnvRule returns a secretEnvRule with its default settings.
+func newSecretEnvRule() rule {
+	return &secretEnvRule{
+		Names:     []string{"^AWS_SECRET", "PASSWORD", "TOKEN"},
+		Values:    []string{`^AKIA[0-9A-Z]{16}$`},
+		Redact:    true,
+		BuildArgs: true,
+	}
+}
+
+// Name implements rule for secretEnvRule.
+func (r *secretEnvRule) Name() string {
+	return "secret-env"
+}
+
+// Code implements rule for secretEnvRule.
+func (r *secretEnvRule) Code() string {
+	return "DUH-SECRET-ENV"
+}
+
+// validate implements validator for secretEnvRule.
+func (r *secretEnvRule) validate() error {
+	var err error
+	if r.names, err = compileAll(r.Names); err != nil {
+		return fmt.Errorf("names: %v", err)
+	}
+	if r.values, err = compileAll(r.Values); err != nil {
+		return fmt.Errorf("values: %v", err)
+	}
+	return nil
+}
+
+// Evaluate implements rule for secretEnvRule.
+func (r *secretEnvRule) Evaluate(ctx *evalContext) decision {
+	if ctx.isBuild() && r.BuildArgs {
+		return r.evaluateBuildArgs(ctx)
+	}
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	secrets := make(map[string]bool)
+	for _, e := range toStrings(ctx.body["Env"]) {
+		if kv := strings.SplitN(e, "=", 2); len(kv) == 2 && r.secret(kv[0], &kv[1]) {
+			secrets[kv[0]] = true
+		}
+	}
+	if len(secrets) == 0 {
+		return allow()
+	}
+	var names []string
+	for k := range secrets {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return deny("environment variables that look like secrets are not allowed: %s", strings.Join(names, ", "))
+}
+
+// evaluateBuildArgs checks the build args of an image build, passed in the
+// buildargs query parameter of /build as a JSON object. Args without a value
+// are null, and are only checked by name.
+func (r *secretEnvRule) evaluateBuildArgs(ctx *evalContext) decision {
+	raw := ctx.query().Get("buildargs")
+	if raw == "" {
+		return allow()
+	}
+	var args map[string]*string
+	if err := json.Unmarshal([]byte(raw), &args); err != nil {
+		return deny("build args are malformed, and cannot be checked for secrets: %v", err)
+	}
+	var names []string
+	for k, v := range args {
+		if r.secret(k, v) {
+			names = append(names, k)
+		}
+	}
+	if len(names) == 0 {
+		return allow()
+	}
+	sort.Strings(names)
+	return deny("build args that look like secrets are not allowed: %s", strings.Join(names, ", "))
+}
+
+// compileAll compiles a list of regular expressions.
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	var res []*regexp.Regexp
+	for _, e := range exprs {
+		re, err := regexp.Compile(e)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, re)
+	}
+	return res, nil
+}
+
+// matchRegexps returns true if s matches any of res.
+func matchRegexps(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
+
+// secret returns true if the variable name with value looks like a secret.
+// value is nil for variables without one.
+func (r *secretEnvRule) secret(name string, value *string) bool {
+	if inList(r.Allow, name) {
+		return false
+	}
+	return matchRegexps(r.names, name) || (value != nil && matchRegexps(r.values, *value))
+}
+
+// redactEnv returns env for logging, with the values of variables that look
+// like secrets replaced with redactedValue if Redact is set. This is used for
+// the Env logged with every request, so that values are redacted whether or
+// not the rule is enabled, and whichever rule decides the request.
+func (r *secretEnvRule) redactEnv(env []string) []interface{} {
+	var logged []interface{}
+	for _, e := range env {
+		if kv := strings.SplitN(e, "=", 2); r.Redact && len(kv) == 2 && r.secret(kv[0], &kv[1]) {
+			e = kv[0] + "=" + redactedValue
+		}
+		logged = append(logged, e)
+	}
+	return logged
+}
+
+// redactURI returns the API request URI uri for logging and forwarding, with
+// the values of the build args of image builds replaced with redactedValue, as
+// they are secrets more often than not. Only the names of the args are kept.
+// A query string that can't be parsed is replaced as a whole, as it may still
+// hold build args.
+func redactURI(uri string) string {
+	i := strings.Index(uri, "?")
+	if i < 0 {
+		return uri
+	}
+	q, err := url.ParseQuery(uri[i+1:])
+	if err != nil {
+		return uri[:i+1] + redactedValue
+	}
+	raw, ok := q["buildargs"]
+	if !ok {
+		return uri
+	}
+	for j, v := range raw {
+		var args map[string]interface{}
+		if json.Unmarshal([]byte(v), &args) != nil {
+			raw[j] = redactedValue
+			continue
+		}
+		for k := range args {
+			args[k] = redactedValue
+		}
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		enc.Encode(args)
+		raw[j] = strings.TrimSpace(b.String())
+	}
+	return uri[:i+1] + q.Encode()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// servicesRule denies creating and updating swarm services that would run
+// containers the other rules deny. Services are created on swarm managers
+// without a call to /containers/create, so the rules for containers never see
+// them.
+type servicesRule struct {
+	ruleOptions
+
+	// DenyCaps is the list of capabilities that services may not add, the
+	// same as the default deny list of the capabilities rule by default.
+	// CapabilityAdd ALL is always denied.
+	DenyCaps []string `json:"deny-caps"`
+
+	// DenyBinds is the list of host paths that services may not mount, the
+	// same as the default deny list of the binds rule by default.
+	DenyBinds []string `json:"deny-binds"`
+
+	// AllowCredentialSpec allows services with a Windows credential spec,
+	// which gives their containers the domain identity of a gMSA account.
+	AllowCredentialSpec bool `json:"allow-credential-spec"`
+
+	// MinHostPort is the lowest port that can be published in host mode
+	// without being in AllowHostPorts.
+	MinHostPort int64 `json:"min-host-port"`
+
+	// AllowHostPorts is a list of ports below MinHostPort that may be
+	// published in host mode.
+	AllowHostPorts []int64 `json:"allow-host-ports"`
+}
+
+// newServicesRule returns a servicesRule with its default settings.
+func newServicesRule() rule {
+	return &servicesRule{
+		DenyCaps:    append([]string(nil), defaultDenyCaps...),
+		DenyBinds:   append([]string(nil), defaultDenyBinds...),
+		MinHostPort: 1024,
+	}
+}
+
+// Name implements rule for servicesRule.
+func (r *servicesRule) Name() string {
+	return "services"
+}
+
+// Code implements rule for servicesRule.
+func (r *servicesRule) Code() string {
+	return "DUH-SERVICE"
+}
+
+// Evaluate implements rule for servicesRule.
+func (r *servicesRule) Evaluate(ctx *evalContext) decision {
+	spec, ok := ctx.serviceSpec()
+	if !ok {
+		return allow()
+	}
+	ctx.logData["Service"] = spec.Name
+	denied := make(map[string]bool)
+	for _, c := range r.DenyCaps {
+		denied[normalizeCap(c)] = true
+	}
+	var caps []string
+	for _, c := range spec.CapAdd {
+		if c = normalizeCap(c); c == "ALL" {
+			return deny("service %s: CapabilityAdd ALL is not allowed", spec.Name)
+		}
+		if denied[c] {
+			caps = append(caps, c)
+		}
+	}
+	sort.Strings(caps)
+	switch len(caps) {
+	case 0:
+	case 1:
+		return deny("service %s: adding capability %s is not allowed", spec.Name, caps[0])
+	default:
+		return deny("service %s: adding capabilities %s is not allowed", spec.Name, strings.Join(caps, ", "))
+	}
+	for _, m := range spec.Mounts {
+		src := m.hostSource()
+		if src == "" {
+			continue
+		}
+		for _, p := range r.DenyBinds {
+			if pathHasPrefix(src, p) {
+				return deny("service %s: bind mount of host path %s is not allowed", spec.Name, src)
+			}
+		}
+	}
+	switch {
+	case spec.CredentialSpec != "" && !r.AllowCredentialSpec:
+		return deny("service %s: credential spec %s is not allowed", spec.Name, spec.CredentialSpec)
+	case spec.SELinuxDisabled:
+		return deny("service %s: disabling SELinux labeling is not allowed", spec.Name)
+	case spec.AppArmorMode == "disabled":
+		return deny("service %s: disabling AppArmor is not allowed", spec.Name)
+	case spec.SeccompMode == "unconfined":
+		return deny("service %s: running without seccomp is not allowed", spec.Name)
+	}
+	for _, p := range spec.Ports {
+		if p.PublishMode != "host" || p.PublishedPort == 0 || p.PublishedPort >= r.MinHostPort || r.allowedPort(p.PublishedPort) {
+			continue
+		}
+		return deny("service %s: publishing port %d on privileged host port %d is not allowed", spec.Name, p.TargetPort, p.PublishedPort)
+	}
+	return allow()
+}
+
+// allowedPort returns true if port is in AllowHostPorts.
+func (r *servicesRule) allowedPort(port int64) bool {
+	for _, p := range r.AllowHostPorts {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
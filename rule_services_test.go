@@ -0,0 +1,83 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestServicesRule(t *testing.T) {
+	fixture, err := ioutil.ReadFile(filepath.Join("testdata", "service_create.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// service returns a request to uri with the body of the fixture, as sent
+	// by docker service create, changed by edit.
+	service := func(uri string, edit func(spec, cs obj)) authzReq {
+		spec, err := decodeBody(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if edit != nil {
+			edit(spec, getMap(getMap(spec, "TaskTemplate"), "ContainerSpec"))
+		}
+		return newAuthzReq("POST", uri, spec)
+	}
+	create := func(edit func(spec, cs obj)) authzReq {
+		return service("/v1.41/services/create", edit)
+	}
+	hostPort := func(published int) func(spec, cs obj) {
+		return func(spec, cs obj) {
+			spec["EndpointSpec"] = obj{"Ports": arr{obj{"Protocol": "tcp", "TargetPort": 80, "PublishedPort": published, "PublishMode": "host"}}}
+		}
+	}
+	p := testPolicy(t, "services.enabled=true", "services.allow-host-ports=[443]")
+	runRuleCases(t, p, []ruleCase{
+		{"fixture", create(nil), true, ""},
+		{"update fixture", service("/v1.41/services/abc/update?version=12", nil), true, ""},
+		{"cap add", create(func(spec, cs obj) { cs["CapabilityAdd"] = arr{"CAP_SYS_ADMIN", "CAP_NET_ADMIN"} }), false, "service web: adding capabilities NET_ADMIN, SYS_ADMIN is not allowed"},
+		{"cap add ALL", create(func(spec, cs obj) { cs["CapabilityAdd"] = arr{"ALL"} }), false, "service web: CapabilityAdd ALL is not allowed"},
+		{"update cap add", service("/services/abc/update", func(spec, cs obj) { cs["CapabilityAdd"] = arr{"SYS_PTRACE"} }), false, "service web: adding capability SYS_PTRACE is not allowed"},
+		{"bind etc", create(func(spec, cs obj) {
+			cs["Mounts"] = arr{obj{"Type": "bind", "Source": "/etc/", "Target": "/host/etc"}}
+		}), false, "service web: bind mount of host path /etc is not allowed"},
+		{"volume named etc", create(func(spec, cs obj) {
+			cs["Mounts"] = arr{obj{"Type": "volume", "Source": "etc", "Target": "/etc"}}
+		}), true, ""},
+		{"SELinux disabled", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"SELinuxContext": obj{"Disable": true}}
+		}), false, "service web: disabling SELinux labeling is not allowed"},
+		{"AppArmor disabled", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"AppArmor": obj{"Mode": "disabled"}}
+		}), false, "service web: disabling AppArmor is not allowed"},
+		{"seccomp unconfined", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"Seccomp": obj{"Mode": "unconfined"}}
+		}), false, "service web: running without seccomp is not allowed"},
+		{"credential spec", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"CredentialSpec": obj{"Config": "gmsa-web"}}
+		}), false, "service web: credential spec config://gmsa-web is not allowed"},
+		{"credential spec file", service("/services/abc/update", func(spec, cs obj) {
+			cs["Privileges"] = obj{"CredentialSpec": obj{"File": "web.json"}}
+		}), false, "service web: credential spec file://web.json is not allowed"},
+		{"empty credential spec", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"CredentialSpec": obj{"Config": ""}}
+		}), true, ""},
+		{"seccomp default", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"Seccomp": obj{"Mode": "default"}}
+		}), true, ""},
+		{"host port", create(hostPort(80)), false, "service web: publishing port 80 on privileged host port 80 is not allowed"},
+		{"allowed host port", create(hostPort(443)), true, ""},
+		{"high host port", create(hostPort(8080)), true, ""},
+		{"ingress low port", create(func(spec, cs obj) {
+			spec["EndpointSpec"] = obj{"Ports": arr{obj{"TargetPort": 80, "PublishedPort": 80}}}
+		}), true, ""},
+		{"no task template", newAuthzReq("POST", "/services/create", obj{"Name": "empty"}), true, ""},
+		{"inspect", newAuthzReq("GET", "/services/abc", nil), true, ""},
+		{"container create", newAuthzReq("POST", "/containers/create", createBody(obj{"CapAdd": arr{"SYS_ADMIN"}})), true, ""},
+	})
+	runRuleCases(t, testPolicy(t, "services.enabled=true", "services.allow-credential-spec=true"), []ruleCase{
+		{"credential spec allowed", create(func(spec, cs obj) {
+			cs["Privileges"] = obj{"CredentialSpec": obj{"Registry": "web"}}
+		}), true, ""},
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+// shmSizeRule denies container creation when HostConfig.ShmSize exceeds a
+// maximum size.
+type shmSizeRule struct {
+	ruleOptions
+
+	// Max is the maximum allowed size of /dev/shm.
+	Max byteSize `json:"max"`
+}
+
+// newShmSizeRule returns a shmSizeRule with its default settings.
+func newShmSizeRule() rule {
+	return &shmSizeRule{
+		ruleOptions: ruleOptions{Enabled: true},
+		Max:         1 << 30,
+	}
+}
+
+// Name implements rule for shmSizeRule.
+func (r *shmSizeRule) Name() string {
+	return "shm-size"
+}
+
+// Code implements rule for shmSizeRule.
+func (r *shmSizeRule) Code() string {
+	return "DUH-SHM-SIZE"
+}
+
+// Evaluate implements rule for shmSizeRule.
+func (r *shmSizeRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	// An unset or zero ShmSize gets the daemon's default of 64m.
+	if v, ok := toInt64(ctx.hostConfig()["ShmSize"]); ok && byteSize(v) > r.Max {
+		return deny("--shm-size of %s exceeds the maximum of %s", byteSize(v), r.Max)
+	}
+	return allow()
+}
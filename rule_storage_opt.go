@@ -0,0 +1,65 @@
+package main
+
+import "sort"
+
+// storageOptRule denies container creation when HostConfig.StorageOpt sets
+// storage driver options that are not allowed, or a size above a maximum.
+type storageOptRule struct {
+	ruleOptions
+	exemptions
+
+	// Allow is a list of allowed --storage-opt keys. An empty list denies any
+	// --storage-opt.
+	Allow []string `json:"allow"`
+
+	// MaxSize is the maximum allowed size option. Zero disables the maximum.
+	MaxSize byteSize `json:"max-size"`
+}
+
+// newStorageOptRule returns a storageOptRule with its default settings.
+func newStorageOptRule() rule {
+	return &storageOptRule{
+		Allow:   []string{"size"},
+		MaxSize: 10 << 30,
+	}
+}
+
+// Name implements rule for storageOptRule.
+func (r *storageOptRule) Name() string {
+	return "storage-opt"
+}
+
+// Code implements rule for storageOptRule.
+func (r *storageOptRule) Code() string {
+	return "DUH-STORAGE-OPT"
+}
+
+// Evaluate implements rule for storageOptRule.
+func (r *storageOptRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	opts := getMap(ctx.hostConfig(), "StorageOpt")
+	var keys []string
+	for k := range opts {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !inList(r.Allow, k) {
+			return deny("--storage-opt %s is not allowed", k)
+		}
+		if k != "size" || r.MaxSize == 0 {
+			continue
+		}
+		s := getString(opts, k)
+		size, err := parseByteSize(s)
+		if err != nil {
+			return deny("--storage-opt size=%s is not a valid size", s)
+		}
+		if size > r.MaxSize {
+			return deny("--storage-opt size=%s exceeds the maximum of %s", size, r.MaxSize)
+		}
+	}
+	return allow()
+}
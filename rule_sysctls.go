@@ -0,0 +1,85 @@
+package main
+
+import (
+	"sort"
+	"strings"
+)
+
+// sysctlsRule denies container creation when HostConfig.Sysctls contains a
+// key with a denied prefix, or a key that is not on the allowlist.
+type sysctlsRule struct {
+	ruleOptions
+
+	// Deny is a list of sysctl key prefixes that are not allowed to be set.
+	Deny []string `json:"deny"`
+
+	// Allow is a list of glob patterns for sysctl keys that are allowed to be
+	// set. If this is empty, all keys not matched by Deny are allowed.
+	Allow []string `json:"allow"`
+}
+
+// newSysctlsRule returns a sysctlsRule with its default settings.
+func newSysctlsRule() rule {
+	return &sysctlsRule{
+		ruleOptions: ruleOptions{Enabled: true},
+		Deny:        []string{"kernel."},
+		Allow: []string{
+			"net.core.somaxconn",
+			"net.ipv4.ip_local_port_range",
+			"net.ipv4.tcp_fin_timeout",
+			"net.ipv4.tcp_keepalive_intvl",
+			"net.ipv4.tcp_keepalive_probes",
+			"net.ipv4.tcp_keepalive_time",
+			"net.ipv4.tcp_tw_reuse",
+		},
+	}
+}
+
+// Name implements rule for sysctlsRule.
+func (r *sysctlsRule) Name() string {
+	return "sysctls"
+}
+
+// Code implements rule for sysctlsRule.
+func (r *sysctlsRule) Code() string {
+	return "DUH-SYSCTL"
+}
+
+// Evaluate implements rule for sysctlsRule.
+func (r *sysctlsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	sysctls := getMap(ctx.hostConfig(), "Sysctls")
+	switch keys := r.denied(sysctls); len(keys) {
+	case 0:
+		return allow()
+	case 1:
+		return deny("sysctl %s is not allowed", keys[0])
+	default:
+		return deny("sysctls %s are not allowed", strings.Join(keys, ", "))
+	}
+}
+
+// denied returns the keys in sysctls that match one of the denied prefixes,
+// or that do not match the allowlist, in sorted order.
+func (r *sysctlsRule) denied(sysctls map[string]interface{}) []string {
+	var keys []string
+	for k := range sysctls {
+		if r.isDenied(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// isDenied returns true if the sysctl key k is not allowed.
+func (r *sysctlsRule) isDenied(k string) bool {
+	for _, p := range r.Deny {
+		if strings.HasPrefix(k, p) {
+			return true
+		}
+	}
+	return len(r.Allow) > 0 && !matchAny(r.Allow, k)
+}
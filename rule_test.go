@@ -0,0 +1,106 @@
+package main
+
+import "testing"
+
+func TestAPIPath(t *testing.T) {
+	cases := []struct {
+		uri, want string
+	}{
+		{"/containers/create", "/containers/create"},
+		{"/v1.41/containers/create", "/containers/create"},
+		{"/v1.24/containers/create?name=foo", "/containers/create"},
+		{"/containers/create?name=foo&platform=linux", "/containers/create"},
+		{"/v2/containers/create", "/containers/create"},
+		{"/v1.41/containers/abc/exec", "/containers/abc/exec"},
+		{"/volumes/create", "/volumes/create"},
+		{"/version", "/version"},
+		{"/vfoo/containers/create", "/vfoo/containers/create"},
+		{"/v/containers/create", "/v/containers/create"},
+		{"/v1.41/containers/create?name=%zz", "/containers/create"},
+	}
+	for _, c := range cases {
+		t.Run(c.uri, func(t *testing.T) {
+			if got := apiPath(c.uri); got != c.want {
+				t.Fatalf("expected %q, got %q", c.want, got)
+			}
+		})
+	}
+}
+
+// TestCreateURIForms checks that container creates are recognized whatever the
+// form of the request URI the daemon passes on.
+func TestCreateURIForms(t *testing.T) {
+	var cases []ruleCase
+	for _, uri := range []string{
+		"/containers/create",
+		"/v1.41/containers/create",
+		"/containers/create?name=foo",
+		"/v1.24/containers/create?name=foo&platform=linux%2Famd64",
+	} {
+		cases = append(cases,
+			ruleCase{uri, newAuthzReq("POST", uri, createBody(obj{"UsernsMode": "host"})), false, "userns=host is not allowed"},
+			ruleCase{uri + " allowed", newAuthzReq("POST", uri, createBody(nil)), true, ""},
+		)
+	}
+	cases = append(cases, ruleCase{"other endpoint", newAuthzReq("POST", "/v1.41/containers/create/extra?x=1", createBody(obj{"UsernsMode": "host"})), true, ""})
+	runRuleCases(t, activePolicy(), cases)
+}
+
+func TestRuleScope(t *testing.T) {
+	userns := newAuthzReq("POST", "/v1.41/containers/create", createBody(obj{"UsernsMode": "host"}))
+	cases := []struct {
+		name     string
+		settings []string
+		allow    bool
+	}{
+		{"default scope", nil, false},
+		{"scoped to PUT", []string{"userns.methods=PUT"}, true},
+		{"scoped to post", []string{"userns.methods=PUT,post"}, false},
+		{"other endpoints", []string{"userns.endpoints=/volumes/*,/networks/create"}, true},
+		{"matching endpoint glob", []string{"userns.endpoints=/containers/*"}, false},
+		{"method and endpoint", []string{"userns.methods=PUT", "userns.endpoints=/containers/create"}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			d, _ := evaluate(t, testPolicy(t, c.settings...), userns)
+			if d.Allow != c.allow {
+				t.Fatalf("expected allowed to be %t, got %t (%s)", c.allow, d.Allow, d.Msg)
+			}
+		})
+	}
+}
+
+func TestRulePhases(t *testing.T) {
+	privileged := createBody(obj{"Privileged": true})
+	userns := createBody(obj{"UsernsMode": "host"})
+	cases := []struct {
+		name     string
+		settings []string
+		path     string
+		body     obj
+		allow    bool
+	}{
+		{"request", []string{"privileged.enabled=true"}, "/AuthZPlugin.AuthZReq", privileged, false},
+		{"response, req only by default", []string{"privileged.enabled=true"}, "/AuthZPlugin.AuthZRes", privileged, true},
+		{"response, both phases", []string{"privileged.enabled=true", "privileged.phases=req,res"}, "/AuthZPlugin.AuthZRes", privileged, false},
+		{"request, res only", []string{"privileged.enabled=true", "privileged.phases=res"}, "/AuthZPlugin.AuthZReq", privileged, true},
+		{"userns request", nil, "/AuthZPlugin.AuthZReq", userns, false},
+		{"userns response, both by default", nil, "/AuthZPlugin.AuthZRes", userns, false},
+		{"userns response, req only", []string{"userns.phases=req"}, "/AuthZPlugin.AuthZRes", userns, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			useTestPolicy(t, testPolicy(t, c.settings...))
+			_, resp := serve(t, c.path, newAuthzReq("POST", "/containers/create", c.body))
+			if resp.Allow != c.allow {
+				t.Fatalf("expected allowed to be %t, got %+v", c.allow, resp)
+			}
+		})
+	}
+
+	var l settingList
+	l.Set("userns.phases=request")
+	if _, err := loadPolicy("", l); err == nil {
+		t.Fatal("expected an error for an invalid phase")
+	}
+}
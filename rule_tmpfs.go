@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// tmpfsRule denies container creation when a tmpfs mount allows executables,
+// is larger than a maximum size, or is mounted at a target path that is not
+// allowed or is denied.
+type tmpfsRule struct {
+	ruleOptions
+	exemptions
+
+	// DenyExec denies --tmpfs mounts with the exec option.
+	DenyExec bool `json:"deny-exec"`
+
+	// MaxSize is the maximum allowed size of a tmpfs mount. When set, tmpfs
+	// mounts must set a size, as they default to half of the host's memory.
+	// Zero disables the maximum.
+	MaxSize byteSize `json:"max-size"`
+
+	// AllowTargets is a list of glob patterns for the container paths that
+	// tmpfs mounts may be mounted at. An empty list allows any path.
+	AllowTargets []string `json:"allow-targets"`
+
+	// DenyTargets is a list of glob patterns for container paths that tmpfs
+	// mounts may not be mounted at, ie: /etc.
+	DenyTargets []string `json:"deny-targets"`
+}
+
+// tmpfsMount is a tmpfs mount from either HostConfig.Tmpfs or
+// HostConfig.Mounts.
+type tmpfsMount struct {
+	// Target is the container path of the mount.
+	Target string
+
+	// Exec is true if the mount allows executables.
+	Exec bool
+
+	// Size is the size of the mount, or zero if unset.
+	Size byteSize
+
+	// RelativeSize is the size option when given as a percentage of the
+	// host's memory, ie: 50%.
+	RelativeSize string
+
+	// Flag is the docker run flag that the mount was given with, for
+	// messages.
+	Flag string
+}
+
+// newTmpfsRule returns a tmpfsRule with its default settings.
+func newTmpfsRule() rule {
+	return &tmpfsRule{
+		DenyExec: true,
+	}
+}
+
+// Name implements rule for tmpfsRule.
+func (r *tmpfsRule) Name() string {
+	return "tmpfs"
+}
+
+// Code implements rule for tmpfsRule.
+func (r *tmpfsRule) Code() string {
+	return "DUH-TMPFS"
+}
+
+// Evaluate implements rule for tmpfsRule.
+func (r *tmpfsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() || r.exempt(ctx) {
+		return allow()
+	}
+	mounts, err := ctx.tmpfsMounts()
+	if err != nil {
+		return deny("malformed tmpfs mount: %v", err)
+	}
+	for _, m := range mounts {
+		if matchAny(r.DenyTargets, m.Target) || len(r.AllowTargets) > 0 && !matchAny(r.AllowTargets, m.Target) {
+			return deny("%s mount at %s is not allowed", m.Flag, m.Target)
+		}
+		if r.DenyExec && m.Exec {
+			return deny("%s mount at %s with exec is not allowed", m.Flag, m.Target)
+		}
+		if r.MaxSize > 0 && m.RelativeSize != "" {
+			return deny("%s mount at %s with a size of %s is not allowed, the maximum is %s", m.Flag, m.Target, m.RelativeSize, r.MaxSize)
+		}
+		if r.MaxSize > 0 && m.Size == 0 {
+			return deny("%s mount at %s must set a size of at most %s", m.Flag, m.Target, r.MaxSize)
+		}
+		if r.MaxSize > 0 && m.Size > r.MaxSize {
+			return deny("%s mount at %s with a size of %s exceeds the maximum of %s", m.Flag, m.Target, m.Size, r.MaxSize)
+		}
+	}
+	return allow()
+}
+
+// tmpfsMounts returns the tmpfs mounts for the container, from both
+// HostConfig.Tmpfs and tmpfs-type HostConfig.Mounts.
+func (c *evalContext) tmpfsMounts() ([]tmpfsMount, error) {
+	var mounts []tmpfsMount
+	tmpfs := getMap(c.hostConfig(), "Tmpfs")
+	var targets []string
+	for target := range tmpfs {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		opts, ok := tmpfs[target].(string)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected a string of options, got %T", target, tmpfs[target])
+		}
+		m, err := parseTmpfsOptions(opts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", target, err)
+		}
+		m.Target = path.Clean(target)
+		m.Flag = "--tmpfs"
+		mounts = append(mounts, m)
+	}
+	for _, m := range c.mounts() {
+		if m.Type != "tmpfs" {
+			continue
+		}
+		tm := tmpfsMount{Target: path.Clean(m.Target), Flag: "--mount type=tmpfs"}
+		if m.TmpfsOptions != nil {
+			tm.Size = byteSize(m.TmpfsOptions.SizeBytes)
+		}
+		mounts = append(mounts, tm)
+	}
+	return mounts, nil
+}
+
+// parseTmpfsOptions parses the comma-separated mount options of a
+// HostConfig.Tmpfs entry, ie: "rw,exec,size=64m". As with mount(8), the last
+// of exec or noexec wins. Docker mounts tmpfs noexec by default.
+func parseTmpfsOptions(opts string) (tmpfsMount, error) {
+	var m tmpfsMount
+	for _, o := range strings.Split(opts, ",") {
+		k := strings.TrimSpace(o)
+		var v string
+		if i := strings.Index(k, "="); i >= 0 {
+			k, v = k[:i], k[i+1:]
+		}
+		switch k {
+		case "exec":
+			m.Exec = true
+		case "noexec":
+			m.Exec = false
+		case "size":
+			if strings.HasSuffix(v, "%") {
+				m.Size, m.RelativeSize = 0, v
+				continue
+			}
+			m.RelativeSize = ""
+			size, err := parseByteSize(v)
+			if err != nil {
+				return tmpfsMount{}, fmt.Errorf("size=%s: %v", v, err)
+			}
+			m.Size = size
+		}
+	}
+	return m, nil
+}
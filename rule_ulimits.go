@@ -0,0 +1,84 @@
+package main
+
+// ulimitsRule denies container creation when an entry in HostConfig.Ulimits
+// exceeds the configured cap for that limit.
+type ulimitsRule struct {
+	ruleOptions
+
+	// Limits holds the caps for each ulimit, keyed by name (ie: nofile).
+	Limits map[string]ulimitCap `json:"limits"`
+
+	// DenyUnknown denies ulimits that do not have an entry in Limits. By
+	// default they are allowed.
+	DenyUnknown bool `json:"deny-unknown"`
+}
+
+// ulimitCap is the cap for a single ulimit.
+type ulimitCap struct {
+	// The maximum soft and hard limits. Either can be omitted to leave that
+	// limit uncapped.
+	Soft *int64 `json:"soft"`
+	Hard *int64 `json:"hard"`
+
+	// Deny denies setting the ulimit at all.
+	Deny bool `json:"deny"`
+}
+
+// newUlimitsRule returns a ulimitsRule with its default settings.
+func newUlimitsRule() rule {
+	return &ulimitsRule{}
+}
+
+// Name implements rule for ulimitsRule.
+func (r *ulimitsRule) Name() string {
+	return "ulimits"
+}
+
+// Code implements rule for ulimitsRule.
+func (r *ulimitsRule) Code() string {
+	return "DUH-ULIMIT"
+}
+
+// Evaluate implements rule for ulimitsRule.
+func (r *ulimitsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	ulimits := getSlice(ctx.hostConfig(), "Ulimits")
+	for _, v := range ulimits {
+		u, _ := v.(map[string]interface{})
+		name := getString(u, "Name")
+		c, ok := r.Limits[name]
+		switch {
+		case !ok && r.DenyUnknown:
+			return deny("ulimit %s is not allowed", name)
+		case !ok:
+			continue
+		case c.Deny:
+			return deny("ulimit %s is not allowed", name)
+		}
+		if d := checkUlimit(name, "soft", u["Soft"], c.Soft); !d.Allow {
+			return d
+		}
+		if d := checkUlimit(name, "hard", u["Hard"], c.Hard); !d.Allow {
+			return d
+		}
+	}
+	return allow()
+}
+
+// checkUlimit checks a single soft or hard value of the named ulimit against
+// max. A value of -1 means unlimited, and always exceeds a cap.
+func checkUlimit(name, kind string, v interface{}, max *int64) decision {
+	if max == nil {
+		return allow()
+	}
+	n, ok := toInt64(v)
+	if !ok {
+		return allow()
+	}
+	if n < 0 || n > *max {
+		return deny("ulimit %s %s limit of %d exceeds the maximum of %d", name, kind, n, *max)
+	}
+	return allow()
+}
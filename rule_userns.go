@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// usernsRule denies container creation that opts out of the daemon's user
+// namespace remapping, ie: with
+// { "HostConfig": { "UsernsMode": "host" } } set in the request body.
+//
+// This is the original check this plugin was written for, and serves as the
+// reference implementation for all other rules.
+type usernsRule struct {
+	ruleOptions
+
+	// DenyModes is the list of UsernsMode values that opt out of remapping.
+	// An entry of "container:*" denies sharing the user namespace of any
+	// other container.
+	DenyModes []string `json:"deny-modes"`
+
+	// Require is a UsernsMode value that containers must set, ie: private.
+	// When set, DenyModes is ignored.
+	Require string `json:"require"`
+
+	// DaemonConfig is the path to the Docker daemon configuration, read at
+	// startup to log whether the daemon remaps user namespaces.
+	DaemonConfig string `json:"daemon-config"`
+}
+
+// newUsernsRule returns a usernsRule with its default settings.
+func newUsernsRule() rule {
+	return &usernsRule{
+		// This rule has always been enforced on both phases, and is kept that
+		// way.
+		ruleOptions:  ruleOptions{Enabled: true, Phases: []string{phaseReq, phaseRes}},
+		DenyModes:    []string{"host"},
+		DaemonConfig: "/etc/docker/daemon.json",
+	}
+}
+
+// Name implements rule for usernsRule.
+func (r *usernsRule) Name() string {
+	return "userns"
+}
+
+// Code implements rule for usernsRule.
+func (r *usernsRule) Code() string {
+	return "DUH-USERNS-HOST"
+}
+
+// Evaluate implements rule for usernsRule.
+func (r *usernsRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	v := getString(ctx.hostConfig(), "UsernsMode")
+	ctx.logData["UsernsMode"] = v
+	if r.Require != "" {
+		if v != r.Require {
+			return deny("userns=%q is not allowed, userns=%s is required", v, r.Require)
+		}
+		return allow()
+	}
+	for _, m := range r.DenyModes {
+		if usernsModeMatch(m, v) {
+			return deny("userns=%s is not allowed", v)
+		}
+	}
+	return allow()
+}
+
+// usernsModeMatch returns true if the UsernsMode v matches the deny-modes
+// entry m. An entry in the form "kind:*" matches any "kind:<value>" mode, ie:
+// "container:*" matches "container:db" but not "containers", and other
+// entries must match the mode exactly.
+func usernsModeMatch(m, v string) bool {
+	if kind := strings.TrimSuffix(m, "*"); kind != m && strings.HasSuffix(kind, ":") {
+		return strings.HasPrefix(v, kind)
+	}
+	return v == m
+}
+
+// validate implements validator for usernsRule. It does not check anything,
+// but logs the daemon's user namespace remapping status if it can be found.
+func (r *usernsRule) validate() error {
+	if r.Enabled && r.DaemonConfig != "" {
+		log.Infof("Daemon user namespace remapping: %s", daemonRemapStatus(r.DaemonConfig))
+	}
+	return nil
+}
+
+// daemonRemapStatus returns a description of the userns-remap setting in the
+// daemon configuration at path.
+func daemonRemapStatus(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "disabled (no daemon configuration at " + path + ")"
+	}
+	if err != nil {
+		return "unknown (" + err.Error() + ")"
+	}
+	var c struct {
+		UsernsRemap string `json:"userns-remap"`
+	}
+	if err := json.Unmarshal(b, &c); err != nil {
+		return "unknown (error parsing " + path + ": " + err.Error() + ")"
+	}
+	if c.UsernsRemap == "" {
+		return "disabled in " + path + " (can still be set with dockerd --userns-remap)"
+	}
+	return "enabled in " + path + " (" + c.UsernsRemap + ")"
+}
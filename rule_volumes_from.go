@@ -0,0 +1,56 @@
+package main
+
+import "strings"
+
+// volumesFromRule denies container creation when HostConfig.VolumesFrom is
+// set, as inheriting another container's mounts can bring in host binds that
+// would otherwise be denied.
+type volumesFromRule struct {
+	ruleOptions
+
+	// Allow is a list of glob patterns for source container names or IDs that
+	// are allowed to be used with --volumes-from.
+	Allow []string `json:"allow"`
+}
+
+// newVolumesFromRule returns a volumesFromRule with its default settings.
+func newVolumesFromRule() rule {
+	return &volumesFromRule{}
+}
+
+// Name implements rule for volumesFromRule.
+func (r *volumesFromRule) Name() string {
+	return "volumes-from"
+}
+
+// Code implements rule for volumesFromRule.
+func (r *volumesFromRule) Code() string {
+	return "DUH-VOLUMES-FROM"
+}
+
+// Evaluate implements rule for volumesFromRule.
+func (r *volumesFromRule) Evaluate(ctx *evalContext) decision {
+	if !ctx.isContainerCreate() {
+		return allow()
+	}
+	volumesFrom := getSlice(ctx.hostConfig(), "VolumesFrom")
+	for _, v := range volumesFrom {
+		s, _ := v.(string)
+		if name := volumesFromSource(s); !matchAny(r.Allow, name) {
+			return deny("--volumes-from %s is not allowed", name)
+		}
+	}
+	return allow()
+}
+
+// volumesFromSource returns the container name or ID of a
+// HostConfig.VolumesFrom entry, in the format container[:ro|:rw].
+func volumesFromSource(s string) string {
+	if i := strings.LastIndex(s, ":"); i >= 0 {
+		switch s[i+1:] {
+		case "ro", "rw":
+			s = s[:i]
+		}
+	}
+	return strings.TrimPrefix(s, "/")
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// selfTest turns on running selfTestCases through the handler at startup,
+// before serving.
+var selfTest bool
+
+// selfTestCase is a canned authorization request for the startup self-test,
+// along with the decision it must get.
+type selfTestCase struct {
+	name  string
+	req   authzReq
+	allow bool
+}
+
+// selfTestCases are the requests run by the startup self-test: a container
+// with userns=host, which must be denied, and an authenticated ping, which
+// must be allowed.
+var selfTestCases = []selfTestCase{
+	{
+		name: "userns=host container create",
+		req: authzReq{
+			RequestMethod: "POST",
+			RequestURI:    "/containers/create",
+			RequestBody:   []byte(`{"Image":"busybox","HostConfig":{"UsernsMode":"host"}}`),
+		},
+		allow: false,
+	},
+	{
+		name: "ping",
+		req: authzReq{
+			User:            "selftest",
+			UserAuthNMethod: "TLS",
+			RequestMethod:   "GET",
+			RequestURI:      "/_ping",
+		},
+		allow: true,
+	},
+}
+
+// runSelfTest sends selfTestCases through authzHandler in-process, and
+// returns an error for the first request that doesn't get the expected
+// decision. This catches broken packaging or policy before the plugin starts
+// allowing everything.
+func runSelfTest() error {
+	for _, c := range selfTestCases {
+		b, err := json.Marshal(c.req)
+		if err != nil {
+			return err
+		}
+		w := httptest.NewRecorder()
+		authzHandler(w, httptest.NewRequest("POST", "/AuthZPlugin.AuthZReq", bytes.NewReader(b)))
+		var resp authResponse
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			return fmt.Errorf("%s: error parsing response: %v", c.name, err)
+		}
+		switch {
+		case w.Code != http.StatusOK || resp.Err != "":
+			return fmt.Errorf("%s: request failed with status %d: %s", c.name, w.Code, resp.Err)
+		case resp.Allow != c.allow:
+			return fmt.Errorf("%s: expected allowed to be %t, got %t (%s)", c.name, c.allow, resp.Allow, resp.Msg)
+		}
+	}
+	log.Info("Self-test passed")
+	return nil
+}
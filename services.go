@@ -0,0 +1,96 @@
+package main
+
+import "strings"
+
+// serviceSpec holds the parts of a swarm service spec, from POST
+// /services/create or /services/{id}/update, that are checked by rules. The
+// container settings of a service are nested under TaskTemplate.ContainerSpec
+// and named differently from HostConfig, so the spec is decoded separately.
+type serviceSpec struct {
+	Name string
+
+	// CapAdd is ContainerSpec.CapabilityAdd.
+	CapAdd []string
+
+	// Mounts is ContainerSpec.Mounts, in the same format as HostConfig.Mounts.
+	Mounts []mount
+
+	// CredentialSpec is ContainerSpec.Privileges.CredentialSpec, the Windows
+	// gMSA credential spec that gives the containers a domain identity, as
+	// one of config://<name>, file://<name>, or registry://<name>. It is
+	// empty if not set.
+	CredentialSpec string
+
+	// SELinuxDisabled is ContainerSpec.Privileges.SELinuxContext.Disable.
+	SELinuxDisabled bool
+
+	// AppArmorMode and SeccompMode are the Mode of
+	// ContainerSpec.Privileges.AppArmor and Seccomp, ie: disabled or
+	// unconfined.
+	AppArmorMode string
+	SeccompMode  string
+
+	// Ports is EndpointSpec.Ports.
+	Ports []servicePort
+}
+
+// servicePort is a published port of a service.
+type servicePort struct {
+	TargetPort    int64
+	PublishedPort int64
+
+	// PublishMode is ingress (the default), to publish the port on every node
+	// through the routing mesh, or host, to publish it on the node running
+	// the task.
+	PublishMode string
+}
+
+// serviceSpec decodes the service spec in the request body. ok is false if
+// the request doesn't create or update a service.
+func (c *evalContext) serviceSpec() (spec serviceSpec, ok bool) {
+	if c.req.RequestMethod != "POST" {
+		return spec, false
+	}
+	parts := strings.Split(c.path(), "/")
+	switch {
+	case c.path() == "/services/create":
+	case len(parts) == 4 && parts[1] == "services" && parts[2] != "" && parts[3] == "update":
+	default:
+		return spec, false
+	}
+	cs := getMap(getMap(c.body, "TaskTemplate"), "ContainerSpec")
+	priv := getMap(cs, "Privileges")
+	cred := getMap(priv, "CredentialSpec")
+	spec = serviceSpec{
+		Name:            getString(c.body, "Name"),
+		CapAdd:          toStrings(cs["CapabilityAdd"]),
+		Mounts:          decodeMounts(getSlice(cs, "Mounts")),
+		SELinuxDisabled: getBool(getMap(priv, "SELinuxContext"), "Disable"),
+		CredentialSpec:  credentialSpec(cred),
+		AppArmorMode:    getString(getMap(priv, "AppArmor"), "Mode"),
+		SeccompMode:     getString(getMap(priv, "Seccomp"), "Mode"),
+	}
+	for _, v := range getSlice(getMap(c.body, "EndpointSpec"), "Ports") {
+		p, _ := v.(map[string]interface{})
+		target, _ := toInt64(p["TargetPort"])
+		published, _ := toInt64(p["PublishedPort"])
+		spec.Ports = append(spec.Ports, servicePort{
+			TargetPort:    target,
+			PublishedPort: published,
+			PublishMode:   getString(p, "PublishMode"),
+		})
+	}
+	return spec, true
+}
+
+// credentialSpec returns the credential spec cs, a
+// ContainerSpec.Privileges.CredentialSpec, in the form <source>://<name>, ie:
+// config://gmsa, or an empty string if none is set.
+func credentialSpec(cs map[string]interface{}) string {
+	for _, k := range []string{"Config", "File", "Registry"} {
+		if v := getString(cs, k); v != "" {
+			return strings.ToLower(k) + "://" + v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+)
+
+// envPrefix is the prefix for environment variables that configure the
+// plugin.
+const envPrefix = "DUH_"
+
+// setting is an override for a single policy setting, applied on top of the
+// policy file.
+type setting struct {
+	// The setting, in the form rule.setting (ie: privileged.enabled), or just
+	// the name for top-level settings (ie: code-prefix).
+	key string
+
+	// The value of the setting. Strings, lists of strings (comma-separated),
+	// and maps of strings (comma-separated key=value pairs) are given as-is.
+	// Anything else is given as JSON, with invalid JSON treated as a string.
+	value string
+
+	// Where the setting came from, for logging.
+	source string
+}
+
+// settingList is a flag.Value that collects settings from repeated -set
+// flags.
+type settingList []setting
+
+// String implements flag.Value for settingList.
+func (l *settingList) String() string {
+	var s []string
+	for _, v := range *l {
+		s = append(s, v.key+"="+v.value)
+	}
+	return strings.Join(s, " ")
+}
+
+// Set implements flag.Value for settingList.
+func (l *settingList) Set(s string) error {
+	kv := strings.SplitN(s, "=", 2)
+	if len(kv) != 2 {
+		return fmt.Errorf("setting must be in the form rule.setting=value")
+	}
+	*l = append(*l, setting{key: kv[0], value: kv[1], source: "flag -set"})
+	return nil
+}
+
+// envName returns the environment variable for a setting key, ie:
+// DUH_NO_NEW_PRIVILEGES_EXEMPT_IMAGES for no-new-privileges.exempt-images.
+func envName(key string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+}
+
+// envSettings returns settings from the environment for all known policy
+// settings. Unknown variables with the DUH_ prefix are logged as a warning.
+func envSettings() []setting {
+	known := map[string]bool{
+		envName("config"):  true,
+		envName("debug"):   true,
+		envName("events"):  true,
+		envName("metrics"): true,
+		envName("socket"):  true,
+
+		envName("request-id-header"): true,
+
+		envName("log-level"):           true,
+		envName("log-syslog"):          true,
+		envName("log-syslog-facility"): true,
+		envName("log-syslog-tag"):      true,
+		envName("log-stderr"):          true,
+		envName("log-unknown-fields"):  true,
+	}
+	var keys []string
+	for k := range settingFields(&policyFile{}) {
+		keys = append(keys, k)
+	}
+	for _, newRule := range ruleRegistry {
+		r := newRule()
+		for k := range settingFields(r) {
+			keys = append(keys, r.Name()+"."+k)
+		}
+	}
+
+	var settings []setting
+	for _, k := range keys {
+		name := envName(k)
+		known[name] = true
+		if v, ok := os.LookupEnv(name); ok {
+			settings = append(settings, setting{key: k, value: v, source: "environment " + name})
+		}
+	}
+	for _, e := range os.Environ() {
+		if name := strings.SplitN(e, "=", 2)[0]; strings.HasPrefix(name, envPrefix) && !known[name] {
+			log.Warnf("Ignoring unknown setting in environment: %s", name)
+		}
+	}
+	return settings
+}
+
+// settingFields returns the settable fields of the struct pointed to by v,
+// keyed by their json name. Fields of embedded structs are included, and the
+// rules section of the policy file is skipped.
+func settingFields(v interface{}) map[string]reflect.Value {
+	fields := make(map[string]reflect.Value)
+	var walk func(v reflect.Value)
+	walk = func(v reflect.Value) {
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if f.Anonymous && f.Type.Kind() == reflect.Struct {
+				walk(v.Field(i))
+				continue
+			}
+			name := strings.Split(f.Tag.Get("json"), ",")[0]
+			if f.PkgPath != "" || name == "" || name == "-" || name == "rules" {
+				continue
+			}
+			fields[name] = v.Field(i)
+		}
+	}
+	walk(reflect.ValueOf(v).Elem())
+	return fields
+}
+
+// applySettings applies the settings in overrides that belong to v, where
+// prefix is the rule name followed by a dot, or empty for top-level settings.
+// Applied settings are removed from overrides.
+func applySettings(v interface{}, prefix string, overrides map[string]setting) error {
+	for name, f := range settingFields(v) {
+		s, ok := overrides[prefix+name]
+		if !ok {
+			continue
+		}
+		if err := setField(f, s.value); err != nil {
+			return fmt.Errorf("invalid value for %s from %s: %v", s.key, s.source, err)
+		}
+		log.Infof("Setting %s from %s", s.key, s.source)
+		delete(overrides, s.key)
+	}
+	return nil
+}
+
+// setField sets the field f to the setting value in s, replacing its current
+// value.
+func setField(f reflect.Value, s string) error {
+	var raw []byte
+	switch {
+	case f.Kind() == reflect.String:
+		raw, _ = json.Marshal(s)
+	case f.Type() == reflect.TypeOf([]string(nil)):
+		var l []string
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				l = append(l, v)
+			}
+		}
+		raw, _ = json.Marshal(l)
+	case f.Type() == reflect.TypeOf(map[string]string(nil)):
+		m := make(map[string]string)
+		for _, v := range strings.Split(s, ",") {
+			if v = strings.TrimSpace(v); v == "" {
+				continue
+			}
+			kv := strings.SplitN(v, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("%q is not in the form key=value", v)
+			}
+			m[kv[0]] = kv[1]
+		}
+		raw, _ = json.Marshal(m)
+	case json.Valid([]byte(s)):
+		raw = []byte(s)
+	default:
+		raw, _ = json.Marshal(s)
+	}
+	f.Set(reflect.Zero(f.Type()))
+	return json.Unmarshal(raw, f.Addr().Interface())
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// byteSize is a size in bytes. In the policy file, it can be given as either
+// a JSON number of bytes, or a string with a unit suffix as accepted by the
+// docker CLI (ie: "512m", "1g"). Units are binary, so 1k is 1024 bytes.
+type byteSize int64
+
+// sizeUnits are the unit suffixes for byteSize, from largest to smallest.
+var sizeUnits = []struct {
+	suffix string
+	size   int64
+}{
+	{"t", 1 << 40},
+	{"g", 1 << 30},
+	{"m", 1 << 20},
+	{"k", 1 << 10},
+	{"b", 1},
+}
+
+// parseByteSize parses a size string such as "512m" or "1g".
+func parseByteSize(s string) (byteSize, error) {
+	v := strings.TrimSuffix(strings.ToLower(strings.TrimSpace(s)), "b")
+	mult := int64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(v, u.suffix) {
+			v = strings.TrimSuffix(v, u.suffix)
+			mult = u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return byteSize(n * float64(mult)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler for byteSize.
+func (b *byteSize) UnmarshalJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case float64:
+		*b = byteSize(v)
+	case string:
+		n, err := parseByteSize(v)
+		if err != nil {
+			return err
+		}
+		*b = n
+	default:
+		return fmt.Errorf("invalid size %s", data)
+	}
+	return nil
+}
+
+// String returns the size in the largest unit that it is a whole multiple of,
+// ie: 1g for 1073741824.
+func (b byteSize) String() string {
+	for _, u := range sizeUnits {
+		if b != 0 && int64(b)%u.size == 0 {
+			return fmt.Sprintf("%d%s", int64(b)/u.size, u.suffix)
+		}
+	}
+	return fmt.Sprintf("%db", int64(b))
+}
@@ -0,0 +1,107 @@
+//go:build integration
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// These tests serve the plugin API on a real UNIX socket, the way the daemon
+// talks to the plugin, and are only built with -tags integration.
+
+// serveSocket serves the plugin API on a UNIX socket in a temporary
+// directory until the end of the test, and returns an HTTP client that
+// connects to it.
+func serveSocket(t *testing.T) *http.Client {
+	dir, err := ioutil.TempDir("", "denyusernshost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "denyusernshost.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	registerHandlers(mux)
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(l)
+	t.Cleanup(func() {
+		srv.Close()
+		os.RemoveAll(dir)
+	})
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		},
+	}
+}
+
+func TestSocketActivate(t *testing.T) {
+	c := serveSocket(t)
+	r, err := c.Post("http://plugin/Plugin.Activate", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Body.Close()
+	b, _ := ioutil.ReadAll(r.Body)
+	if r.StatusCode != http.StatusOK || string(b) != `{"Implements":["authz"]}` {
+		t.Fatalf("unexpected activation response %s: %s", r.Status, b)
+	}
+}
+
+func TestSocketAuthz(t *testing.T) {
+	c := serveSocket(t)
+	cases := []struct {
+		path    string
+		fixture string
+		allow   bool
+	}{
+		{"/AuthZPlugin.AuthZReq", "create_allowed.json", true},
+		{"/AuthZPlugin.AuthZReq", "create_userns_host.json", false},
+		{"/AuthZPlugin.AuthZRes", "create_response.json", true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.path+" "+tc.fixture, func(t *testing.T) {
+			b, err := ioutil.ReadFile(filepath.Join("testdata", tc.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := c.Post("http://plugin"+tc.path, "application/json", bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Body.Close()
+			var resp authResponse
+			if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if r.StatusCode != http.StatusOK || r.Header.Get("Content-Type") != "application/json" || resp.Allow != tc.allow || resp.Err != "" {
+				t.Fatalf("expected allowed %t, got %s with %+v", tc.allow, r.Status, resp)
+			}
+		})
+	}
+}
+
+func TestSocketUnknownURL(t *testing.T) {
+	c := serveSocket(t)
+	r, err := c.Get("http://plugin/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Body.Close()
+	if r.StatusCode != http.StatusNotFound {
+		t.Fatalf("expected 404, got %s", r.Status)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log/syslog"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	logrus_syslog "github.com/Sirupsen/logrus/hooks/syslog"
+)
+
+// syslogConfig holds the syslog logging flags.
+type syslogConfig struct {
+	Enabled  bool
+	Facility string
+	Tag      string
+	Stderr   bool
+}
+
+// logSyslog is the syslog configuration set from the command line.
+var logSyslog = syslogConfig{
+	Facility: "daemon",
+	Tag:      "denyusernshost",
+	Stderr:   true,
+}
+
+// syslogFacilities maps facility names to their syslog priorities.
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// setup connects to the local syslog daemon and adds a hook sending all log
+// entries to it. When stderr logging is turned off, the standard logger
+// output is discarded and syslog becomes the only destination. Errors that
+// happen while writing to syslog later on are reported on stderr by logrus.
+func (c syslogConfig) setup() error {
+	if !c.Enabled {
+		return nil
+	}
+	facility, ok := syslogFacilities[strings.ToLower(c.Facility)]
+	if !ok {
+		return fmt.Errorf("unknown syslog facility %q", c.Facility)
+	}
+	hook, err := logrus_syslog.NewSyslogHook("", "", facility|syslog.LOG_INFO, c.Tag)
+	if err != nil {
+		return fmt.Errorf("connecting to local syslog: %v", err)
+	}
+	log.AddHook(hook)
+	if !c.Stderr {
+		log.SetOutput(ioutil.Discard)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// testRequestPath is the path to a request body to evaluate offline with
+// -test-request. "-" reads the body from standard input.
+var testRequestPath string
+
+// testRequestURI is the API request URI that the -test-request body is
+// evaluated as.
+var testRequestURI string
+
+// runTestRequest evaluates the request body at path against policy p as an
+// unauthenticated POST to uri, and writes a report of each rule's decision
+// and the final decision to w. It returns 0 if the request is allowed, and 1
+// if it is denied. The program exits with status 2 if the body can't be read.
+func runTestRequest(w io.Writer, p *policy, path, uri string) int {
+	var b []byte
+	var err error
+	if path == "-" {
+		b, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		b, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		errExit(2, "Error reading request body: %v", err)
+	}
+	data, err := decodeBody(b)
+	if err != nil {
+		errExit(2, "Error parsing request body: %v", err)
+	}
+	req := &authzReq{
+		RequestMethod: "POST",
+		RequestURI:    uri,
+		RequestBody:   b,
+	}
+	ctx := &evalContext{req: req, body: data, logData: make(map[string]interface{})}
+
+	fmt.Fprintf(w, "Request: %s %s\n\n", req.RequestMethod, redactURI(req.RequestURI))
+	var width int
+	for _, r := range p.rules {
+		if len(r.Name()) > width {
+			width = len(r.Name())
+		}
+	}
+	for _, r := range p.rules {
+		if !r.options().inScope(ctx) {
+			fmt.Fprintf(w, "  skip   %-*s  (out of scope)\n", width, r.Name())
+			continue
+		}
+		d := r.Evaluate(ctx)
+		switch {
+		case d.Allow:
+			fmt.Fprintf(w, "  allow  %s\n", r.Name())
+		case r.options().Action == actionWarn:
+			fmt.Fprintf(w, "  WARN   %-*s  %s: %s\n", width, r.Name(), r.Code(), d.Msg)
+		case r.options().Action == actionAllow:
+			fmt.Fprintf(w, "  allow  %-*s  (action allow) %s: %s\n", width, r.Name(), r.Code(), d.Msg)
+		default:
+			fmt.Fprintf(w, "  DENY   %-*s  %s: %s\n", width, r.Name(), r.Code(), d.Msg)
+		}
+	}
+
+	d := p.evaluate(ctx)
+	if d.Allow {
+		fmt.Fprintln(w, "\nDecision: allow")
+		return 0
+	}
+	fmt.Fprintf(w, "\nDecision: DENY (%s) - %s\n", d.Code, d.Msg)
+	return 1
+}
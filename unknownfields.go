@@ -0,0 +1,72 @@
+package main
+
+import "sort"
+
+// logUnknownFields turns on logging the HostConfig fields of requests that no
+// rule inspects, to notice fields added by newer API versions.
+var logUnknownFields bool
+
+// inspectedHostConfigFields are the HostConfig fields that at least one rule
+// looks at. Add to this when a rule starts inspecting a new field.
+var inspectedHostConfigFields = map[string]bool{
+	"Binds":                true,
+	"BlkioDeviceReadBps":   true,
+	"BlkioDeviceReadIOps":  true,
+	"BlkioDeviceWriteBps":  true,
+	"BlkioDeviceWriteIOps": true,
+	"BlkioWeight":          true,
+	"CapAdd":               true,
+	"CapDrop":              true,
+	"CgroupParent":         true,
+	"CpuPeriod":            true,
+	"CpuQuota":             true,
+	"CpuShares":            true,
+	"DeviceCgroupRules":    true,
+	"Dns":                  true,
+	"DnsOptions":           true,
+	"DnsSearch":            true,
+	"ExtraHosts":           true,
+	"GroupAdd":             true,
+	"Init":                 true,
+	"IpcMode":              true,
+	"Links":                true,
+	"LogConfig":            true,
+	"MaskedPaths":          true,
+	"Memory":               true,
+	"MemorySwap":           true,
+	"Mounts":               true,
+	"NanoCpus":             true,
+	"NetworkMode":          true,
+	"OomKillDisable":       true,
+	"OomScoreAdj":          true,
+	"PidMode":              true,
+	"PidsLimit":            true,
+	"PortBindings":         true,
+	"Privileged":           true,
+	"PublishAllPorts":      true,
+	"ReadonlyPaths":        true,
+	"ReadonlyRootfs":       true,
+	"RestartPolicy":        true,
+	"Runtime":              true,
+	"SecurityOpt":          true,
+	"ShmSize":              true,
+	"StorageOpt":           true,
+	"Sysctls":              true,
+	"Tmpfs":                true,
+	"Ulimits":              true,
+	"UsernsMode":           true,
+	"VolumesFrom":          true,
+}
+
+// unknownHostConfigFields returns the sorted keys of hc that no rule
+// inspects.
+func unknownHostConfigFields(hc map[string]interface{}) []string {
+	var unknown []string
+	for k := range hc {
+		if !inspectedHostConfigFields[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
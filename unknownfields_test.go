@@ -0,0 +1,25 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnknownHostConfigFields(t *testing.T) {
+	cases := []struct {
+		name string
+		hc   obj
+		want []string
+	}{
+		{"empty", obj{}, nil},
+		{"inspected", obj{"Binds": nil, "UsernsMode": "", "CapAdd": arr{}}, nil},
+		{"unknown", obj{"Privileged": true, "Devices": arr{}, "CpusetCpus": "0", "Annotations": obj{}}, []string{"Annotations", "CpusetCpus", "Devices"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := unknownHostConfigFields(c.hc); !reflect.DeepEqual(got, c.want) {
+				t.Fatalf("expected %v, got %v", c.want, got)
+			}
+		})
+	}
+}
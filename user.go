@@ -0,0 +1,41 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+)
+
+// splitUser splits a user specification in the form user[:group], as given
+// to --user, into its user and group parts. Either can be a name or a numeric
+// ID.
+func splitUser(spec string) (user, group string) {
+	if i := strings.Index(spec, ":"); i >= 0 {
+		return spec[:i], spec[i+1:]
+	}
+	return spec, ""
+}
+
+// isRootUser returns true if the user specification runs as root: it is
+// empty, meaning the default of root unless the image sets USER, or its user
+// part is root or UID 0, whatever the group.
+func isRootUser(spec string) bool {
+	user, _ := splitUser(strings.TrimSpace(spec))
+	if user == "" || user == "root" {
+		return true
+	}
+	// Parse numeric IDs the same way as the runtime, so that ie: 00 is caught.
+	uid, err := strconv.Atoi(user)
+	return err == nil && uid == 0
+}
+
+// sameID returns true if a and b are the same user or group, either by name,
+// or as numeric IDs, so that ie: 00 is the same as 0.
+func sameID(a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if a == b {
+		return true
+	}
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	return errA == nil && errB == nil && x == y
+}
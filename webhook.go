@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webhookCode is the reason code reported when the webhook denies a request,
+// or when it fails and fail-open is not set.
+const webhookCode = "DUH-WEBHOOK"
+
+// webhookOptions are the top-level policy settings for the decision webhook.
+type webhookOptions struct {
+	// WebhookURL is the URL of an external policy service to consult for
+	// requests the local rules allow. Empty disables the webhook.
+	WebhookURL string `json:"webhook-url"`
+
+	// WebhookTimeout is how long to wait for the webhook to respond.
+	WebhookTimeout duration `json:"webhook-timeout"`
+
+	// WebhookFailOpen allows requests when the webhook can't be reached, times
+	// out, or returns an error. Otherwise they are denied.
+	WebhookFailOpen bool `json:"webhook-fail-open"`
+}
+
+// duration is a time.Duration that is given in the policy file as a string
+// accepted by time.ParseDuration, ie: "5s".
+type duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler for duration.
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// webhook sends requests to an external policy service for a decision.
+type webhook struct {
+	url      string
+	failOpen bool
+	client   *http.Client
+
+	// secretEnv redacts secrets in the Env sent to the webhook, the same way
+	// as in the logs.
+	secretEnv *secretEnvRule
+}
+
+// webhookRequest is the body POSTed to the webhook.
+type webhookRequest struct {
+	User            string
+	UserAuthNMethod string
+	RequestMethod   string
+	RequestURI      string
+	RequestBody     map[string]interface{}
+}
+
+// webhookResponse is the body expected back from the webhook.
+type webhookResponse struct {
+	Allow bool
+	Msg   string
+}
+
+// newWebhook returns a webhook for the settings in o, or nil if the webhook is
+// disabled.
+func newWebhook(o webhookOptions) (*webhook, error) {
+	if o.WebhookURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(o.WebhookURL)
+	if err != nil || u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("webhook-url: %q is not an http or https URL", o.WebhookURL)
+	}
+	if o.WebhookTimeout <= 0 {
+		return nil, fmt.Errorf("webhook-timeout: must be positive")
+	}
+	return &webhook{
+		url:      o.WebhookURL,
+		failOpen: o.WebhookFailOpen,
+		client:   &http.Client{Timeout: time.Duration(o.WebhookTimeout)},
+	}, nil
+}
+
+// evaluate asks the webhook for a decision on the request in ctx. If the
+// webhook fails, the request is allowed or denied depending on fail-open.
+func (w *webhook) evaluate(ctx *evalContext) decision {
+	resp, err := w.call(ctx)
+	if err != nil {
+		if ctx.context().Err() != nil {
+			ctx.logger().Warnf("Webhook call canceled, denying request: %v", err)
+			return deny("the request was canceled before it could be checked")
+		}
+		if w.failOpen {
+			ctx.logger().Warnf("Webhook failed, allowing request (fail-open): %v", err)
+			ctx.logData["Webhook"] = "failed-open"
+			return allow()
+		}
+		ctx.logger().Errorf("Webhook failed, denying request: %v", err)
+		ctx.logData["Webhook"] = "failed-closed"
+		return deny("the external policy check failed")
+	}
+	if !resp.Allow {
+		if resp.Msg == "" {
+			resp.Msg = "denied by external policy"
+		}
+		return deny("%s", resp.Msg)
+	}
+	return allow()
+}
+
+// body returns the request body in ctx to send to the webhook, with secrets in
+// Env redacted if the secret-env rule is set to redact them, whether or not it
+// is enabled.
+func (w *webhook) body(ctx *evalContext) map[string]interface{} {
+	if _, ok := ctx.body["Env"]; !ok || w.secretEnv == nil || !w.secretEnv.Redact {
+		return ctx.body
+	}
+	body := make(map[string]interface{}, len(ctx.body))
+	for k, v := range ctx.body {
+		body[k] = v
+	}
+	body["Env"] = w.secretEnv.redactEnv(toStrings(ctx.body["Env"]))
+	return body
+}
+
+// call POSTs the request in ctx to the webhook and decodes its response.
+func (w *webhook) call(ctx *evalContext) (*webhookResponse, error) {
+	b, err := json.Marshal(webhookRequest{
+		User:            ctx.req.User,
+		UserAuthNMethod: ctx.req.UserAuthNMethod,
+		RequestMethod:   ctx.req.RequestMethod,
+		RequestURI:      redactURI(ctx.req.RequestURI),
+		RequestBody:     w.body(ctx),
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", w.url, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	r, err := w.client.Do(req.WithContext(ctx.context()))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Body.Close()
+	if r.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(r.Body, 512))
+		return nil, fmt.Errorf("%s returned %s: %s", w.url, r.Status, strings.TrimSpace(string(msg)))
+	}
+	var resp webhookResponse
+	if err := json.NewDecoder(r.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("error parsing response from %s: %v", w.url, err)
+	}
+	return &resp, nil
+}
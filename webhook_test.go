@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testWebhook starts a webhook server that allows every request, and records
+// the requests it gets.
+func testWebhook(t *testing.T) (url string, requests func() []webhookRequest) {
+	var mu sync.Mutex
+	var got []webhookRequest
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req webhookRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("error decoding webhook request: %v", err)
+		}
+		mu.Lock()
+		got = append(got, req)
+		mu.Unlock()
+		w.Write([]byte(`{"Allow": true}`))
+	}))
+	t.Cleanup(s.Close)
+	return s.URL, func() []webhookRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]webhookRequest(nil), got...)
+	}
+}
+
+func TestWebhookPhases(t *testing.T) {
+	url, requests := testWebhook(t)
+	useTestPolicy(t, testPolicyFile(t, `{"webhook-url": "`+url+`"}`))
+	req := newAuthzReq("POST", "/v1.41/containers/create", createBody(nil))
+	if _, resp := serve(t, "/AuthZPlugin.AuthZReq", req); !resp.Allow {
+		t.Fatalf("expected the request to be allowed, got %+v", resp)
+	}
+	if n := len(requests()); n != 1 {
+		t.Fatalf("expected 1 webhook call for AuthZReq, got %d", n)
+	}
+	if _, resp := serve(t, "/AuthZPlugin.AuthZRes", req); !resp.Allow {
+		t.Fatalf("expected the response to be allowed, got %+v", resp)
+	}
+	if n := len(requests()); n != 1 {
+		t.Fatalf("expected no webhook call for AuthZRes, got %d calls in all", n)
+	}
+}
+
+func TestWebhookRedaction(t *testing.T) {
+	body := createBody(nil)
+	body["Env"] = arr{"PATH=/bin", "GITHUB_TOKEN=s3cr3t-value"}
+	cases := []struct {
+		name     string
+		settings []string
+		env      []string
+	}{
+		{"redacted", nil, []string{"PATH=/bin", "GITHUB_TOKEN=" + redactedValue}},
+		{"redact off", []string{"secret-env.redact=false"}, []string{"PATH=/bin", "GITHUB_TOKEN=s3cr3t-value"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			url, requests := testWebhook(t)
+			useTestPolicy(t, testPolicyFile(t, `{"webhook-url": "`+url+`"}`, c.settings...))
+			serve(t, "/AuthZPlugin.AuthZReq", newAuthzReq("POST", "/containers/create", body))
+			serve(t, "/AuthZPlugin.AuthZReq", newAuthzReq("POST", `/build?buildargs={"NPM_TOKEN":"s3cr3t-value"}`, nil))
+			got := requests()
+			if len(got) != 2 {
+				t.Fatalf("expected 2 webhook calls, got %d", len(got))
+			}
+			if env := toStrings(got[0].RequestBody["Env"]); !reflect.DeepEqual(env, c.env) {
+				t.Fatalf("expected Env %v, got %v", c.env, env)
+			}
+			if strings.Contains(got[1].RequestURI, "s3cr3t-value") {
+				t.Fatalf("build arg value sent to the webhook: %s", got[1].RequestURI)
+			}
+		})
+	}
+
+	// The rules still see the request body as is.
+	ctx := &evalContext{body: obj{"Env": arr{"GITHUB_TOKEN=s3cr3t-value"}}}
+	w := &webhook{secretEnv: testPolicy(t).secretEnv}
+	w.body(ctx)
+	if env := toStrings(ctx.body["Env"]); env[0] != "GITHUB_TOKEN=s3cr3t-value" {
+		t.Fatalf("the request body was changed: %v", env)
+	}
+}